@@ -0,0 +1,127 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+)
+
+// ErrNotAppendable is returned by OpenAppender for a file, which does not
+// end with a track segment.
+var ErrNotAppendable = errors.New("GPX file does not end with a track segment")
+
+// appendTail is the size of the file end searched for the closing tags.
+const appendTail = 4096
+
+/*
+Appender appends track points to the last track segment of a GPX file
+without rewriting it, e.g. for a long-running logger. Each Append writes
+its track point to the file directly, but the file is not synced. The
+closing tags are written by Close. A crash before Close leaves the file
+without them, which OpenAppender accepts to continue logging, as long as
+the file ends with a complete track point. The file is not locked: only
+one Appender may write to a file at a time, and readers see a valid GPX
+file only after Close. An Appender is not safe for concurrent use.
+*/
+type Appender struct {
+	f    *os.File
+	b    []byte
+	opts WriteOptions
+}
+
+/*
+OpenAppender opens GPX file path for appending track points to its last
+track segment as written by Marshal. The closing </trkseg></trk></gpx>
+tags are truncated from the file and rewritten by Close. A file, which
+does not exist or is empty, is created with a GPX header and a track
+with an empty segment. The <metadata> bounds of an existing file are not
+updated. Numbers are formatted by DefaultWriteOptions.
+*/
+func OpenAppender(path string) (*Appender, error) {
+	f, e := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if e != nil {
+		return nil, errf("%w", e)
+	}
+	a := &Appender{f: f, opts: DefaultWriteOptions}
+	if e = a.seekEnd(); e != nil {
+		f.Close()
+		return nil, errf("%s: %w", path, e)
+	}
+	return a, nil
+}
+
+// seekEnd truncates the closing tags from the file and seeks to the end.
+// An empty file gets the header.
+func (a *Appender) seekEnd() error {
+	size, e := a.f.Seek(0, io.SeekEnd)
+	if e != nil {
+		return e
+	}
+	if size == 0 {
+		b := (&GPX{}).appendHeader(nil, a.opts)
+		b = append(b, " <trk>\n  <trkseg>\n"...)
+		_, e = a.f.Write(b)
+		return e
+	}
+	base := max(size-appendTail, 0)
+	tail := make([]byte, size-base)
+	if _, e = a.f.ReadAt(tail, base); e != nil {
+		return e
+	}
+	n := appendOffset(tail)
+	if n < 0 {
+		return ErrNotAppendable
+	}
+	if e = a.f.Truncate(base + int64(n)); e != nil {
+		return e
+	}
+	if _, e = a.f.Seek(base+int64(n), io.SeekStart); e != nil {
+		return e
+	}
+	_, e = a.f.WriteString("\n")
+	return e
+}
+
+// appendOffset returns the offset in tail of the closing tags
+// </trkseg> </trk> </gpx>, or of the end of tail, if it ends with a
+// track point or a <trkseg> start tag. Otherwise it returns -1.
+func appendOffset(tail []byte) int {
+	t := bytes.TrimRight(tail, " \t\r\n")
+	if bytes.HasSuffix(t, []byte("</trkpt>")) || bytes.HasSuffix(t, []byte("<trkseg>")) {
+		return len(t) //crashed before Close
+	}
+	for _, tag := range []string{"</gpx>", "</trk>", "</trkseg>"} {
+		if !bytes.HasSuffix(t, []byte(tag)) {
+			return -1
+		}
+		t = bytes.TrimRight(t[:len(t)-len(tag)], " \t\r\n")
+	}
+	return len(t)
+}
+
+// Append writes track point p to the file. A point without valid lat and
+// lon, which Marshal would skip, is not written and ErrInvalidCoordinate
+// is returned.
+func (a *Appender) Append(p Trkpt) error {
+	if !hasCoords(p) {
+		return ErrInvalidCoordinate
+	}
+	a.b = appendTrkpt(a.b[:0], p, a.opts)
+	if _, e := a.f.Write(a.b); e != nil {
+		return errf("%w", e)
+	}
+	return nil
+}
+
+// Close writes the closing tags and closes the file.
+func (a *Appender) Close() error {
+	_, e := a.f.WriteString("  </trkseg>\n </trk>\n</gpx>\n")
+	if e2 := a.f.Close(); e == nil {
+		e = e2
+	}
+	if e != nil {
+		return errf("%w", e)
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// AscentOptions are the parameters of AscentWithOptions.
+type AscentOptions struct {
+	SmoothWindow int           //points of the elevation moving average, <= 1 for none
+	Threshold    float64       //meters a climb must rise and a descent fall to count
+	MinDuration  time.Duration //shorter climbs are not counted, 0 for all
+}
+
+/*
+DefaultAscentOptions are the parameters of AscentLikeStrava. The
+platforms do not publish their exact algorithms, these follow the common
+description of them: elevations are smoothed over 5 points, about 5 s at
+1 s recording, a climb is counted only after a 10 m rise, a commonly
+used threshold for GPS elevation, and climbs taking less than 30 s, e.g.
+elevation jumps at bridges and tunnels, are dropped.
+*/
+var DefaultAscentOptions = AscentOptions{
+	SmoothWindow: 5,
+	Threshold:    10,
+	MinDuration:  30 * time.Second,
+}
+
+// AscentLikeStrava returns the total ascent in meters of the first track
+// segment by AscentWithOptions(DefaultAscentOptions). The result is
+// closer to the ones of popular training platforms than the ascent of
+// ElevationGainLoss, but it is not the same for all tracks.
+func (gpx *GPX) AscentLikeStrava() float64 {
+	return gpx.AscentWithOptions(DefaultAscentOptions)
+}
+
+/*
+AscentWithOptions returns the total ascent in meters of the first track
+segment. The elevations are smoothed as in AnalyzedGain. A climb starts
+from the lowest elevation before it, when elevation has risen
+opts.Threshold meters above it, and it ends at its highest elevation,
+when elevation has fallen opts.Threshold meters below it. The rise of a
+climb is counted, if the climb takes at least opts.MinDuration. Climbs
+with a point without time are counted. Track points without elevation
+are skipped.
+*/
+func (gpx *GPX) AscentWithOptions(opts AscentOptions) (gain float64) {
+	var lowT, peakT time.Time
+
+	s := gpx.firstTrkpts()
+	low, peak := math.NaN(), math.NaN()
+	climbing := false
+	count := func() {
+		if lowT.IsZero() || peakT.IsZero() || peakT.Sub(lowT) >= opts.MinDuration {
+			gain += peak - low
+		}
+	}
+	for i, ele := range movingAverage(gpx.ElevationSeries(), opts.SmoothWindow) {
+		switch t := s[i].Time; {
+		case math.IsNaN(ele):
+		case climbing && ele > peak:
+			peak, peakT = ele, t
+		case climbing && peak-ele >= opts.Threshold:
+			count()
+			climbing = false
+			low, lowT = ele, t
+		case climbing:
+		case math.IsNaN(low) || ele < low:
+			low, lowT = ele, t
+		case ele-low >= opts.Threshold:
+			climbing = true
+			peak, peakT = ele, t
+		}
+	}
+	if climbing {
+		count()
+	}
+	return gain
+}
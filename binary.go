@@ -0,0 +1,204 @@
+package gpx
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrBinaryFormat is returned by UnmarshalBinary for data not written by
+// MarshalBinary or written by a later format version.
+var ErrBinaryFormat = errors.New("invalid binary GPX data")
+
+const (
+	binaryMagic   = "GPXB"
+	binaryVersion = 1
+
+	binaryHasTime  = 1 << 0
+	binaryHasExtra = 1 << 1
+)
+
+/*
+MarshalBinary implements encoding.BinaryMarshaler. The data has all
+tracks and track segments with the header data, track names, comments
+and descriptions, and the track points with times and Extra values, but
+not ErrCount, WarnCount and Offsets. The format, version 1, is
+
+	"GPXB" version byte
+	Creator Version Time Author Link.Href Link.Text Keywords
+	track count, for each track: Name Cmt Desc segment count,
+	for each segment: point count, for each point:
+		flags byte: 1 time, 2 Extra
+		Lat Lon Ele float64
+		Time int64 Unix nanoseconds, if flag 1
+		Extra float64s in field order, if flag 2
+
+Counts are uvarints, strings are a uvarint length and the UTF-8 bytes,
+and float64s and int64s are little-endian. The version is increased on
+any format change and UnmarshalBinary rejects other versions, so cached
+data of an older version must be rebuilt. Times are read back in UTC and
+they must be in the years 1678-2262.
+*/
+func (gpx *GPX) MarshalBinary() ([]byte, error) {
+	b := make([]byte, 0, 64+26*gpx.TotalPoints())
+	b = append(b, binaryMagic...)
+	b = append(b, binaryVersion)
+	m := gpx.Metadata
+	for _, s := range []string{gpx.Creator, gpx.Version, gpx.Time,
+		m.Author, m.Link.Href, m.Link.Text, m.Keywords} {
+		b = appendBinaryString(b, s)
+	}
+	b = binary.AppendUvarint(b, uint64(len(gpx.Trks)))
+	for _, trk := range gpx.Trks {
+		b = appendBinaryString(b, trk.Name)
+		b = appendBinaryString(b, trk.Cmt)
+		b = appendBinaryString(b, trk.Desc)
+		b = binary.AppendUvarint(b, uint64(len(trk.Trksegs)))
+		for _, seg := range trk.Trksegs {
+			b = binary.AppendUvarint(b, uint64(len(seg.Trkpts)))
+			for _, p := range seg.Trkpts {
+				b = appendBinaryTrkpt(b, p)
+			}
+		}
+	}
+	return b, nil
+}
+
+// appendBinaryString appends the length and the bytes of s to b.
+func appendBinaryString(b []byte, s string) []byte {
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+// appendBinaryTrkpt appends track point p to b.
+func appendBinaryTrkpt(b []byte, p Trkpt) []byte {
+	var flags byte
+	if !p.Time.IsZero() {
+		flags |= binaryHasTime
+	}
+	if p.Extra != nil {
+		flags |= binaryHasExtra
+	}
+	b = append(b, flags)
+	for _, f := range []float64{p.Lat, p.Lon, p.Ele} {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(f))
+	}
+	if !p.Time.IsZero() {
+		b = binary.LittleEndian.AppendUint64(b, uint64(p.Time.UnixNano()))
+	}
+	if p.Extra != nil {
+		for _, name := range extraNames {
+			b = binary.LittleEndian.AppendUint64(b, math.Float64bits(*p.Extra.field(name)))
+		}
+	}
+	return b
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler for data written
+// by MarshalBinary. It replaces all data of gpx. For invalid data
+// ErrBinaryFormat is returned and gpx is not changed.
+func (gpx *GPX) UnmarshalBinary(data []byte) error {
+	var g GPX
+
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return ErrBinaryFormat
+	}
+	if v := data[len(binaryMagic)]; v != binaryVersion {
+		return errf("%w: version %d", ErrBinaryFormat, v)
+	}
+	r := &binaryReader{b: data[len(binaryMagic)+1:]}
+	m := &g.Metadata
+	for _, s := range []*string{&g.Creator, &g.Version, &g.Time,
+		&m.Author, &m.Link.Href, &m.Link.Text, &m.Keywords} {
+		*s = r.string()
+	}
+	g.Trks = make([]Trk, r.count(4))
+	for i := range g.Trks {
+		trk := &g.Trks[i]
+		trk.Name, trk.Cmt, trk.Desc = r.string(), r.string(), r.string()
+		trk.Trksegs = make([]Trkseg, r.count(1))
+		for j := range trk.Trksegs {
+			s := make([]Trkpt, r.count(25))
+			for k := range s {
+				s[k] = r.trkpt()
+			}
+			trk.Trksegs[j].Trkpts = s
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	*gpx = g
+	return nil
+}
+
+// binaryReader reads MarshalBinary data from b. After the first error
+// the reads return zero values.
+type binaryReader struct {
+	b   []byte
+	err error
+}
+
+// fail sets r.err to ErrBinaryFormat, if it is not set.
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = ErrBinaryFormat
+	}
+	r.b = nil
+}
+
+// count reads a count of items taking at least size bytes each. A count
+// larger than the remaining data allows fails, so that invalid data does
+// not allocate much.
+func (r *binaryReader) count(size int) int {
+	n, k := binary.Uvarint(r.b)
+	if k <= 0 || n > uint64(len(r.b)-k)/uint64(size) {
+		r.fail()
+		return 0
+	}
+	r.b = r.b[k:]
+	return int(n)
+}
+
+func (r *binaryReader) string() string {
+	n := r.count(1)
+	s := string(r.b[:n])
+	r.b = r.b[n:]
+	return s
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if len(r.b) < 8 {
+		r.fail()
+		return 0
+	}
+	u := binary.LittleEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return u
+}
+
+func (r *binaryReader) float() float64 {
+	return math.Float64frombits(r.uint64())
+}
+
+func (r *binaryReader) trkpt() (p Trkpt) {
+	if len(r.b) == 0 {
+		r.fail()
+		return p
+	}
+	flags := r.b[0]
+	r.b = r.b[1:]
+	p.Lat, p.Lon, p.Ele = r.float(), r.float(), r.float()
+	if flags&binaryHasTime != 0 {
+		p.Time = time.Unix(0, int64(r.uint64())).UTC()
+	}
+	if flags&binaryHasExtra != 0 {
+		x := newTrkptExtra()
+		for _, name := range extraNames {
+			*x.field(name) = r.float()
+		}
+		p.Extra = &x
+	}
+	return p
+}
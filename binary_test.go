@@ -0,0 +1,68 @@
+package gpx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestBinaryRoundTrip(t *testing.T) {
+	tests := []struct {
+		file string
+		opts ParseOptions
+	}{
+		{"multitrack.gpx", ParseOptions{UseXMLParser: true}},
+		{"multitrack.gpx", lenient},
+		{"dop.gpx", lenient},
+		{"magvar.gpx", lenient},
+		{"selfclosing.gpx", lenient},
+		{"entities.gpx", lenient},
+		{"gpx10.gpx", lenient},
+	}
+	for _, tt := range tests {
+		gpx, err := Parse(readTestdata(t, tt.file), tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		b, err := gpx.MarshalBinary()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		var r GPX
+		if err := r.UnmarshalBinary(b); err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if !sameGPX(&r, gpx) {
+			t.Errorf("%s: binary round trip changed the GPX", tt.file)
+		}
+	}
+	if err := (&GPX{}).UnmarshalBinary(nil); !errors.Is(err, ErrBinaryFormat) {
+		t.Errorf("UnmarshalBinary(nil) = %v, want %v", err, ErrBinaryFormat)
+	}
+}
+
+func TestUnmarshalBinaryInvalid(t *testing.T) {
+	b, err := parseTestdata(t, "multitrack.gpx").MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	version := append([]byte(nil), b...)
+	version[len(binaryMagic)]++
+	tests := [][]byte{
+		nil,
+		[]byte("GPX"),
+		[]byte("XXXX\x01"),
+		version,
+		b[:len(b)-1],
+		b[:len(b)/2],
+		append([]byte("GPXB\x01\x00\x00\x00\x00\x00\x00\x00"), 0xff, 0xff, 0xff, 0xff, 0x0f), //huge count
+	}
+	for i, data := range tests {
+		gpx := parseTestdata(t, "short.gpx")
+		if err := gpx.UnmarshalBinary(data); !errors.Is(err, ErrBinaryFormat) {
+			t.Errorf("%d: err = %v, want %v", i, err, ErrBinaryFormat)
+		}
+		if len(gpx.TrkpSlice()) != 3 {
+			t.Errorf("%d: gpx changed on error", i)
+		}
+	}
+}
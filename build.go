@@ -0,0 +1,63 @@
+package gpx
+
+// NewEmpty returns a GPX with a single empty track segment for building
+// a track by AddPoint or Append.
+func NewEmpty() *GPX {
+	gpx := &GPX{}
+	gpx.trkseg()
+	return gpx
+}
+
+// AddPoint appends track point p to the first track segment of gpx,
+// which is created if missing. Set p.Ele to NaN for no elevation.
+func (gpx *GPX) AddPoint(p Trkpt) {
+	seg := gpx.trkseg()
+	seg.Trkpts = append(seg.Trkpts, p)
+}
+
+/*
+Consume appends the track points received from ch to the first track
+segment of gpx as AddPoint, until ch is closed, e.g. in a live GPS
+logger. Consume modifies gpx, so Marshal or EncodeTo must not be called
+during it. To write a track while recording, receive the points and call
+AddPoint and EncodeTo under a lock held by the caller.
+*/
+func (gpx *GPX) Consume(ch <-chan Trkpt) {
+	for p := range ch {
+		gpx.AddPoint(p)
+	}
+}
+
+/*
+Append appends the track points of the first track segment of other to
+the first track segment of gpx. The points are copied with their Extra
+values, so gpx does not alias other.
+
+Append returns the haversine distance in meters between the last point
+of gpx and the first point of other, which tells whether there is a gap
+between the tracks. If either track is empty, the gap is 0.
+*/
+func (gpx *GPX) Append(other *GPX) (gapMeters float64) {
+	s, o := gpx.firstTrkpts(), other.firstTrkpts()
+	if len(o) == 0 {
+		return 0
+	}
+	if len(s) > 0 {
+		gapMeters = dist(s[len(s)-1], o[0])
+	}
+	t := make([]Trkpt, len(s), len(s)+len(o))
+	copy(t, s)
+	gpx.trkseg().Trkpts = append(t, copyTrkpts(o)...)
+	return gapMeters
+}
+
+// trkseg returns the first track segment, which is created if missing.
+func (gpx *GPX) trkseg() *Trkseg {
+	if len(gpx.Trks) == 0 {
+		gpx.Trks = []Trk{{}}
+	}
+	if len(gpx.Trks[0].Trksegs) == 0 {
+		gpx.Trks[0].Trksegs = []Trkseg{{}}
+	}
+	return &gpx.Trks[0].Trksegs[0]
+}
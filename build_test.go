@@ -0,0 +1,37 @@
+package gpx
+
+import "testing"
+
+func TestAppendCopies(t *testing.T) {
+	tests := []struct {
+		dst, src string
+		points   int
+	}{
+		{"dop.gpx", "magvar.gpx", 7},
+		{"empty.gpx", "dop.gpx", 4},
+		{"dop.gpx", "dop.gpx", 8},
+	}
+	for _, tt := range tests {
+		gpx := NewEmpty()
+		if tt.dst != "empty.gpx" {
+			gpx = parseTestdata(t, tt.dst)
+		}
+		other := parseTestdata(t, tt.src)
+		want := other.TrkpSliceCopy()
+		gpx.Append(other)
+		s := gpx.TrkpSlice()
+		if len(s) != tt.points {
+			t.Fatalf("%s + %s: %d points, want %d", tt.dst, tt.src, len(s), tt.points)
+		}
+		for _, p := range other.TrkpSlice() {
+			if p.Extra != nil {
+				p.Extra.HDOP, p.Extra.GeoidHeight = -1, -1
+			}
+		}
+		for i, p := range s[len(s)-len(want):] {
+			if !sameTrkpt(p, want[i]) {
+				t.Errorf("%s + %s: point %d changed by modifying other: %v", tt.dst, tt.src, i, p.Extra)
+			}
+		}
+	}
+}
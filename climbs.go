@@ -0,0 +1,61 @@
+package gpx
+
+import "math"
+
+// Climb is a climb of the first track segment found by Climbs.
+type Climb struct {
+	Start, End int     //indices of the lowest and the highest track point
+	Gain       float64 //elevation difference of End and Start in meters
+	Distance   float64 //distance from Start to End along the track in meters
+	Grade      float64 //Gain / Distance, e.g. 0.05 is 5 %
+}
+
+/*
+Climbs returns the climbs of the first track segment with at least minGain
+meters of gain and an average grade of at least minGrade, e.g. 0.03 for
+3 %. A climb starts at the lowest point before an ascent and ends at its
+highest point. It does not end at minor dips: it ends only when elevation
+falls below its top by more than 10 % of its gain so far, but at least
+DefaultGainThreshold meters. Track points without elevation are skipped.
+*/
+func (gpx *GPX) Climbs(minGain, minGrade float64) []Climb {
+	var climbs []Climb
+
+	s := gpx.firstTrkpts()
+	cum := cumDistances(s)
+	add := func(start, top int) {
+		c := Climb{Start: start, End: top, Gain: s[top].Ele - s[start].Ele}
+		c.Distance = cum[top] - cum[start]
+		if c.Distance <= 0 || c.Gain < minGain {
+			return
+		}
+		if c.Grade = c.Gain / c.Distance; c.Grade >= minGrade {
+			climbs = append(climbs, c)
+		}
+	}
+	start, top := -1, -1
+	for i, p := range s {
+		if math.IsNaN(p.Ele) {
+			continue
+		}
+		if start < 0 {
+			start, top = i, i
+			continue
+		}
+		if p.Ele > s[top].Ele {
+			top = i
+		}
+		gain := s[top].Ele - s[start].Ele
+		switch {
+		case s[top].Ele-p.Ele > max(0.1*gain, DefaultGainThreshold):
+			add(start, top)
+			start, top = i, i
+		case p.Ele < s[start].Ele:
+			start, top = i, i
+		}
+	}
+	if start >= 0 {
+		add(start, top)
+	}
+	return climbs
+}
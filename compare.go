@@ -0,0 +1,137 @@
+package gpx
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+)
+
+/*
+Equal reports whether a and b have the same number of tracks, track
+segments and track points, and the lat, lon and ele values of each pair
+of track points differ at most by epsilon. Missing (NaN) elevations are
+equal. Names, times and other data are not compared. Equal can be used
+to check that ParseGPX and encoding/xml give the same result, or to diff
+a re-parsed file against the original.
+*/
+func Equal(a, b *GPX, epsilon float64) bool {
+	if len(a.Trks) != len(b.Trks) {
+		return false
+	}
+	for i := range a.Trks {
+		sa, sb := a.Trks[i].Trksegs, b.Trks[i].Trksegs
+		if len(sa) != len(sb) {
+			return false
+		}
+		for j := range sa {
+			pa, pb := sa[j].Trkpts, sb[j].Trkpts
+			if len(pa) != len(pb) {
+				return false
+			}
+			for k := range pa {
+				if !near(pa[k].Lat, pb[k].Lat, epsilon) ||
+					!near(pa[k].Lon, pb[k].Lon, epsilon) ||
+					!near(pa[k].Ele, pb[k].Ele, epsilon) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+/*
+Equal reports whether the lat, lon and ele values of p and q differ at
+most by epsilon as in the function Equal, and their times are equal.
+The epsilon of lat and lon is in degrees, not meters, e.g. 1e-7 is
+about 1 cm. Missing elevations are equal, and so are missing times.
+Extra is not compared.
+*/
+func (p Trkpt) Equal(q Trkpt, epsilon float64) bool {
+	return near(p.Lat, q.Lat, epsilon) &&
+		near(p.Lon, q.Lon, epsilon) &&
+		near(p.Ele, q.Ele, epsilon) &&
+		p.Time.Equal(q.Time)
+}
+
+// near reports whether x and y differ at most by epsilon. NaNs are near each other.
+func near(x, y, epsilon float64) bool {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.IsNaN(x) && math.IsNaN(y)
+	}
+	return math.Abs(x-y) <= epsilon
+}
+
+/*
+SimilarityHausdorff returns the symmetric Hausdorff distance in meters
+between the first track segments of a and b: the largest haversine
+distance from a point of either track to the nearest point of the other
+track. The cost is O(n·m) distance computations for tracks of n and m
+points, so long tracks should be downsampled first, e.g. by Resample.
+NaN is returned if either track is empty.
+*/
+func SimilarityHausdorff(a, b *GPX) float64 {
+	sa, sb := a.firstTrkpts(), b.firstTrkpts()
+	if len(sa) == 0 || len(sb) == 0 {
+		return math.NaN()
+	}
+	return max(hausdorff(sa, sb), hausdorff(sb, sa))
+}
+
+// hausdorff returns the directed Hausdorff distance from a to b.
+func hausdorff(a, b []Trkpt) float64 {
+	h := 0.0
+	for _, p := range a {
+		d := math.Inf(1)
+		for _, q := range b {
+			d = min(d, dist(p, q))
+			if d <= h {
+				break //p cannot increase h
+			}
+		}
+		h = max(h, d)
+	}
+	return h
+}
+
+/*
+ClosestApproach returns the indices of the track points of the first track
+segments of a and b, which are closest to each other, and their haversine
+distance in meters, e.g. where two riders met. Track point times are not
+compared. The cost is O(n·m) distance computations as in
+SimilarityHausdorff. If either track is empty, the indices are -1 and the
+distance is NaN.
+*/
+func ClosestApproach(a, b *GPX) (idxA, idxB int, meters float64) {
+	sa, sb := a.firstTrkpts(), b.firstTrkpts()
+	if len(sa) == 0 || len(sb) == 0 {
+		return -1, -1, math.NaN()
+	}
+	meters = math.Inf(1)
+	for i, p := range sa {
+		for j, q := range sb {
+			if d := dist(p, q); d < meters {
+				idxA, idxB, meters = i, j, d
+			}
+		}
+	}
+	return idxA, idxB, meters
+}
+
+/*
+Fingerprint returns a 64-bit FNV-1a hash of the lat and lon sequence of
+all track points, e.g. to detect re-uploads of the same track. The
+coordinates are first quantized to 5 decimals, about 1 m, ties to even,
+so precision noise, e.g. of different output decimals, does not change
+the hash. Names, elevations, times and other data are not hashed.
+*/
+func (gpx *GPX) Fingerprint() uint64 {
+	h := fnv.New64a()
+	var b [16]byte
+	for p := range gpx.AllPoints() {
+		binary.LittleEndian.PutUint64(b[:8], uint64(int64(math.RoundToEven(p.Lat*1e5))))
+		binary.LittleEndian.PutUint64(b[8:], uint64(int64(math.RoundToEven(p.Lon*1e5))))
+		h.Write(b[:])
+	}
+	return h.Sum64()
+}
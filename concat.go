@@ -0,0 +1,55 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+)
+
+/*
+ParseDocuments parses GPX data of one or more concatenated GPX documents,
+e.g. a log file, where each <?xml ...?><gpx>...</gpx> document has been
+appended to the previous ones. The documents are split after each </gpx>
+closing tag and parsed as Parse, and the GPXs are returned in order. A
+last document without </gpx>, e.g. of an interrupted write, is parsed
+too. A document, which fails to parse, has a GPX without tracks and its
+error prefixed by its number is joined to the returned error, so that
+the other documents are still returned. ParseGPX would merge the track
+points of all documents to one segment and take the header data from the
+first document.
+*/
+func ParseDocuments(gpxbytes []byte, opts ParseOptions) ([]*GPX, error) {
+	var gpxs []*GPX
+	var errs []error
+
+	for _, doc := range splitDocuments(gpxbytes) {
+		gpx, e := newGPX("", doc, opts)
+		if e != nil {
+			errs = append(errs, errf("document %d: %w", len(gpxs)+1, e))
+		}
+		gpxs = append(gpxs, gpx)
+	}
+	if len(gpxs) == 0 {
+		return nil, ErrNoTrackpoints
+	}
+	return gpxs, errors.Join(errs...)
+}
+
+// splitDocuments splits b after each </gpx> closing tag. Data after the
+// last one is a document, if it has a <gpx element.
+func splitDocuments(b []byte) [][]byte {
+	var docs [][]byte
+
+	closing := []byte("</gpx>")
+	for {
+		r := bytes.Index(b, closing)
+		if r < 0 {
+			break
+		}
+		docs = append(docs, b[:r+len(closing)])
+		b = b[r+len(closing):]
+	}
+	if indexElement(b, "gpx") >= 0 {
+		docs = append(docs, b)
+	}
+	return docs
+}
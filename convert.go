@@ -0,0 +1,81 @@
+package gpx
+
+import (
+	"compress/gzip"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrUnsupportedFormat is returned by ConvertFile for an unknown format.
+var ErrUnsupportedFormat = errors.New("unsupported output format")
+
+/*
+ConvertFile parses GPX file in, gzip compressed if its name ends with
+.gz, and writes it to file out in format "gpx", "csv", "geojson" or "kml".
+If format is "", it is taken from the extension of out, where .json
+is also geojson. ErrUnsupportedFormat is returned for other formats.
+Track points are parsed as in New without ignoring errors.
+*/
+func ConvertFile(in, out string, format string) error {
+	if format == "" {
+		format = strings.TrimPrefix(filepath.Ext(out), ".")
+	}
+	format = strings.ToLower(format)
+	if format == "json" {
+		format = "geojson"
+	}
+	switch format {
+	case "gpx", "csv", "geojson", "kml":
+	default:
+		return errf("%s: %w: %q", out, ErrUnsupportedFormat, format)
+	}
+	gpx, e := openGPX(in)
+	if e != nil {
+		return e
+	}
+	f, e := os.Create(out)
+	if e != nil {
+		return errf("%w", e)
+	}
+	switch format {
+	case "gpx":
+		e = gpx.EncodeTo(f)
+	case "csv":
+		e = gpx.WriteCSV(f)
+	case "geojson":
+		e = gpx.WriteGeoJSON(f)
+	case "kml":
+		e = gpx.WriteKML(f)
+	}
+	if e2 := f.Close(); e == nil {
+		e = e2
+	}
+	if e != nil {
+		return errf("%s: %w", out, e)
+	}
+	return nil
+}
+
+// openGPX parses GPX file name by New, or by NewReader if the file
+// is gzip compressed and its name ends with .gz.
+func openGPX(name string) (*GPX, error) {
+	if !strings.HasSuffix(name, ".gz") {
+		return New(name, false, false)
+	}
+	f, e := os.Open(name)
+	if e != nil {
+		return &GPX{}, errf("%w", e)
+	}
+	defer f.Close()
+	r, e := gzip.NewReader(f)
+	if e != nil {
+		return &GPX{}, errf("%s: %w", name, e)
+	}
+	gpx, e := NewReader(r, ParseOptions{})
+	if e != nil {
+		return gpx, errf("%s: %w", name, e)
+	}
+	return gpx, nil
+}
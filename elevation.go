@@ -0,0 +1,244 @@
+package gpx
+
+import (
+	"errors"
+	"math"
+)
+
+// DefaultGainThreshold is the elevation change threshold in meters used
+// by VAM. It filters out most GPS elevation noise.
+const DefaultGainThreshold = 5.0
+
+/*
+ElevationGainLoss returns the total ascent and descent in meters of the
+first track segment. An elevation change is counted only after elevation
+has moved more than threshold meters from the last counted elevation,
+which filters out small noisy ups and downs. With threshold 0 all
+changes are counted. Track points without elevation are skipped.
+*/
+func (gpx *GPX) ElevationGainLoss(threshold float64) (gain, loss float64) {
+	c := newClimb(threshold)
+	for _, p := range gpx.firstTrkpts() {
+		c.add(p.Ele)
+	}
+	return c.gain, c.loss
+}
+
+/*
+AnalyzedGain returns the ascent and descent of the first track segment as
+ElevationGainLoss(threshold), but from elevations smoothed by a moving
+average of smoothWindow points, as in SmoothPositions. Smoothing removes
+most of the GPS elevation jitter, which a threshold alone leaves. gpx is
+not changed. AnalyzedGain(5, DefaultGainThreshold) is a good start for
+tracks recorded every few seconds.
+*/
+func (gpx *GPX) AnalyzedGain(smoothWindow int, threshold float64) (gain, loss float64) {
+	c := newClimb(threshold)
+	for _, ele := range movingAverage(gpx.ElevationSeries(), smoothWindow) {
+		c.add(ele)
+	}
+	return c.gain, c.loss
+}
+
+// movingAverage returns the centered moving averages of window values of
+// s. The window shrinks symmetrically at the ends. NaNs are skipped and
+// kept as they are.
+func movingAverage(s []float64, window int) []float64 {
+	half := max(window/2, 0)
+	avg := make([]float64, len(s))
+	for i := range s {
+		avg[i] = s[i]
+		if math.IsNaN(s[i]) {
+			continue
+		}
+		h := min(half, i, len(s)-1-i)
+		sum, n := 0.0, 0
+		for _, f := range s[i-h : i+h+1] {
+			if !math.IsNaN(f) {
+				sum += f
+				n++
+			}
+		}
+		avg[i] = sum / float64(n)
+	}
+	return avg
+}
+
+// climb accumulates elevation gain and loss as in ElevationGainLoss.
+type climb struct {
+	threshold  float64
+	ref        float64 //last counted elevation, NaN before the first one
+	gain, loss float64
+}
+
+func newClimb(threshold float64) climb {
+	return climb{threshold: threshold, ref: math.NaN()}
+}
+
+// add adds elevation ele to c. NaN ele is skipped.
+func (c *climb) add(ele float64) {
+	switch d := ele - c.ref; {
+	case math.IsNaN(ele):
+	case math.IsNaN(c.ref):
+		c.ref = ele
+	case d > c.threshold:
+		c.gain += d
+		c.ref = ele
+	case -d > c.threshold:
+		c.loss -= d
+		c.ref = ele
+	}
+}
+
+// ElevationSeries returns a new slice of the elevations of the first
+// track segment, NaN for missing ones (see FillElevationGaps), or nil if
+// there are none. With CumulativeDistances it is the x/y data of an
+// elevation profile chart.
+func (gpx *GPX) ElevationSeries() []float64 {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return nil
+	}
+	ele := make([]float64, len(s))
+	for i, p := range s {
+		ele[i] = p.Ele
+	}
+	return ele
+}
+
+// ElevationRange returns the minimum and maximum elevation of the first
+// track segment. Missing elevations are skipped. If no track point has
+// elevation, both are NaN.
+func (gpx *GPX) ElevationRange() (lo, hi float64) {
+	lo, hi = math.NaN(), math.NaN()
+	for _, p := range gpx.firstTrkpts() {
+		if math.IsNaN(p.Ele) {
+			continue
+		}
+		if math.IsNaN(lo) {
+			lo, hi = p.Ele, p.Ele
+		}
+		lo = min(lo, p.Ele)
+		hi = max(hi, p.Ele)
+	}
+	return lo, hi
+}
+
+/*
+HasUsefulElevation reports whether the first track segment has at least
+two different elevations. It is false for a track without elevation and
+for a constant one, e.g. <ele>0</ele> of a device without elevation
+data, where ElevationGainLoss and the other elevation analytics give
+nothing useful. Such elevations can be replaced from a DEM by Drop3D and
+FillElevationFrom.
+*/
+func (gpx *GPX) HasUsefulElevation() bool {
+	lo, hi := gpx.ElevationRange()
+	return hi > lo //false for NaN
+}
+
+/*
+VAM returns the mean ascent rate of the first track segment in meters per
+hour, the ascent of ElevationGainLoss(DefaultGainThreshold) divided by
+Duration. VAM depends on the threshold: a smaller one counts more noise
+as ascent. ErrNoTime is returned if Duration fails or is not positive.
+*/
+func (gpx *GPX) VAM() (float64, error) {
+	d, e := gpx.Duration()
+	if e != nil {
+		return 0, e
+	}
+	if d <= 0 {
+		return 0, ErrNoTime
+	}
+	gain, _ := gpx.ElevationGainLoss(DefaultGainThreshold)
+	return gain / d.Hours(), nil
+}
+
+/*
+FillElevationGaps sets the missing (NaN) elevations of the first track
+segment in place. A gap between two points with elevation is linearly
+interpolated by distance along the track, points before the first or
+after the last elevation get that elevation. If no track point has
+elevation, the track is not changed.
+*/
+func (gpx *GPX) FillElevationGaps() {
+	s := gpx.firstTrkpts()
+	prev := -1 //index of the previous point with elevation
+	for i := range s {
+		if math.IsNaN(s[i].Ele) {
+			continue
+		}
+		if prev < 0 {
+			for j := 0; j < i; j++ {
+				s[j].Ele = s[i].Ele
+			}
+		} else if i > prev+1 {
+			fillGap(s[prev : i+1])
+		}
+		prev = i
+	}
+	if prev < 0 {
+		return
+	}
+	for j := prev + 1; j < len(s); j++ {
+		s[j].Ele = s[prev].Ele
+	}
+}
+
+/*
+FillElevationFrom sets the missing (NaN) elevations of the first track
+segment in place from lookup, e.g. a DEM or an elevation API. Points
+with elevation are not changed. A NaN or Inf elevation from lookup is
+not set. The first lookup error aborts filling, it is returned prefixed
+by the track point number and the points before it are filled.
+*/
+func (gpx *GPX) FillElevationFrom(lookup func(lat, lon float64) (float64, error)) error {
+	return gpx.fillElevation(lookup, false)
+}
+
+// FillElevationFromAll is like FillElevationFrom, but lookup errors do not
+// abort: all missing elevations are looked up and the errors are joined.
+func (gpx *GPX) FillElevationFromAll(lookup func(lat, lon float64) (float64, error)) error {
+	return gpx.fillElevation(lookup, true)
+}
+
+// fillElevation fills elevations from lookup, all of them if all is set.
+func (gpx *GPX) fillElevation(lookup func(lat, lon float64) (float64, error), all bool) error {
+	var errs []error
+
+	s := gpx.firstTrkpts()
+	for i := range s {
+		if !math.IsNaN(s[i].Ele) {
+			continue
+		}
+		ele, e := lookup(s[i].Lat, s[i].Lon)
+		if e != nil {
+			e = errf("trackpoint %d: %w", i+1, e)
+			if !all {
+				return e
+			}
+			errs = append(errs, e)
+			continue
+		}
+		if !math.IsNaN(ele) && !math.IsInf(ele, 0) {
+			s[i].Ele = ele
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fillGap interpolates the elevations of s between the first and the
+// last point by distance, or by index if the points are at the same place.
+func fillGap(s []Trkpt) {
+	cum := cumDistances(s)
+	total := cum[len(s)-1]
+	e0, e1 := s[0].Ele, s[len(s)-1].Ele
+	for j := 1; j < len(s)-1; j++ {
+		f := float64(j) / float64(len(s)-1)
+		if total > 0 {
+			f = cum[j] / total
+		}
+		s[j].Ele = e0 + f*(e1-e0)
+	}
+}
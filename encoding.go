@@ -0,0 +1,84 @@
+package gpx
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// declEncoding returns the lower case encoding of the XML declaration
+// at the beginning of b, "" if there is none.
+func declEncoding(b []byte) string {
+	if !bytes.HasPrefix(b, []byte("<?xml")) {
+		return ""
+	}
+	return strings.ToLower(attrText(b, "encoding"))
+}
+
+// textUTF8 returns text b of GPX data transcoded to UTF-8, if data
+// declares a Latin-1 encoding. Otherwise b is returned as it is.
+func textUTF8(data, b []byte) []byte {
+	if isLatin1(declEncoding(data)) {
+		return latin1ToUTF8(b)
+	}
+	return b
+}
+
+// isLatin1 reports whether encoding is ISO-8859-1 or Windows-1252.
+func isLatin1(encoding string) bool {
+	switch strings.ToLower(encoding) {
+	case "iso-8859-1", "iso_8859-1", "latin1", "latin-1", "l1",
+		"windows-1252", "cp1252":
+		return true
+	}
+	return false
+}
+
+/*
+latin1ToUTF8 returns Latin-1 data b transcoded to UTF-8. As in web
+browsers, ISO-8859-1 is decoded as its superset Windows-1252, where the
+bytes 0x80-0x9F are printable characters, e.g. 0x80 is '€'. If b is
+ASCII, b itself is returned.
+*/
+func latin1ToUTF8(b []byte) []byte {
+	i := 0
+	for i < len(b) && b[i] < utf8.RuneSelf {
+		i++
+	}
+	if i == len(b) {
+		return b
+	}
+	u := make([]byte, i, len(b)+len(b)/4)
+	copy(u, b[:i])
+	for _, c := range b[i:] {
+		switch {
+		case c < utf8.RuneSelf:
+			u = append(u, c)
+		case c < 0xA0:
+			u = utf8.AppendRune(u, cp1252[c-0x80])
+		default:
+			u = utf8.AppendRune(u, rune(c))
+		}
+	}
+	return u
+}
+
+// cp1252 has the Windows-1252 characters of bytes 0x80-0x9F. The five
+// unassigned bytes are mapped to the same control characters as in Latin-1.
+var cp1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8D, 'Ž', 0x8F,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9D, 'ž', 'Ÿ',
+}
+
+// charsetReader is the xml.Decoder CharsetReader for Latin-1 data.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	if !isLatin1(charset) {
+		return nil, errf("unsupported XML encoding %q", charset)
+	}
+	b, e := io.ReadAll(input)
+	if e != nil {
+		return nil, e
+	}
+	return bytes.NewReader(latin1ToUTF8(b)), nil
+}
@@ -0,0 +1,53 @@
+package gpx
+
+import "math"
+
+/*
+ToENU returns the track points of the first track segment as local
+East-North-Up coordinates in meters relative to origin (originLat,
+originLon, originEle). The points are converted from WGS-84 geodetic
+coordinates (semi-major axis 6378137 m, flattening 1/298.257223563) to
+ECEF and then rotated to the ENU frame of the origin. The transform is
+exact, but ENU is a tangent plane frame, so Up drops below the Earth
+surface with distance, by about 8 m at 10 km. A point without elevation
+is taken to be at originEle.
+*/
+func (gpx *GPX) ToENU(originLat, originLon, originEle float64) [][3]float64 {
+	const rad = math.Pi / 180
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return nil
+	}
+	x0, y0, z0 := ecef(originLat, originLon, originEle)
+	sinLat, cosLat := math.Sincos(originLat * rad)
+	sinLon, cosLon := math.Sincos(originLon * rad)
+	enu := make([][3]float64, len(s))
+	for i, p := range s {
+		h := p.Ele
+		if math.IsNaN(h) {
+			h = originEle
+		}
+		x, y, z := ecef(p.Lat, p.Lon, h)
+		dx, dy, dz := x-x0, y-y0, z-z0
+		enu[i] = [3]float64{
+			-sinLon*dx + cosLon*dy,
+			-sinLat*cosLon*dx - sinLat*sinLon*dy + cosLat*dz,
+			cosLat*cosLon*dx + cosLat*sinLon*dy + sinLat*dz,
+		}
+	}
+	return enu
+}
+
+// ecef returns the WGS-84 Earth-centered Earth-fixed coordinates in
+// meters of the point at lat, lon in degrees and height h in meters.
+func ecef(lat, lon, h float64) (x, y, z float64) {
+	const rad = math.Pi / 180
+	const e2 = wgs84F * (2 - wgs84F) //first eccentricity squared
+	sinLat, cosLat := math.Sincos(lat * rad)
+	sinLon, cosLon := math.Sincos(lon * rad)
+	n := wgs84A / math.Sqrt(1-e2*sinLat*sinLat) //prime vertical radius
+	x = (n + h) * cosLat * cosLon
+	y = (n + h) * cosLat * sinLon
+	z = (n*(1-e2) + h) * sinLat
+	return x, y, z
+}
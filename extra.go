@@ -0,0 +1,170 @@
+package gpx
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// TrkptExtra holds optional track point data, which most GPX files do not
+// have. A Trkpt has a nil Extra unless some of these elements are present.
+// Missing values are NaN.
+// Extension elements are found by their local names, e.g. <gpxtpx:hr>.
+type TrkptExtra struct {
+	MagVar      float64 // <magvar>, magnetic variation in degrees
+	GeoidHeight float64 // <geoidheight>, geoid height above WGS84 ellipsoid in meters
+	Sat         float64 // <sat>, number of satellites of the fix
+	HDOP        float64 // <hdop>, horizontal dilution of precision
+	VDOP        float64 // <vdop>, vertical dilution of precision
+	PDOP        float64 // <pdop>, position dilution of precision
+	HR          float64 // <extensions> <hr>, heart rate in beats per minute
+	Cad         float64 // <extensions> <cad>, cadence in revolutions per minute
+	Power       float64 // <extensions> <power> or <PowerInWatts>, power in watts
+}
+
+// newTrkptExtra returns TrkptExtra with all values missing.
+func newTrkptExtra() TrkptExtra {
+	nan := math.NaN()
+	return TrkptExtra{MagVar: nan, GeoidHeight: nan, Sat: nan, HDOP: nan, VDOP: nan, PDOP: nan,
+		HR: nan, Cad: nan, Power: nan}
+}
+
+// extraNames are the element names of the TrkptExtra fields.
+var extraNames = []string{"magvar", "geoidheight", "sat", "hdop", "vdop", "pdop", "hr", "cad", "power"}
+
+// field returns a pointer to the field of x for element name,
+// nil if name is not a TrkptExtra element.
+func (x *TrkptExtra) field(name string) *float64 {
+	switch name {
+	case "magvar":
+		return &x.MagVar
+	case "geoidheight":
+		return &x.GeoidHeight
+	case "sat":
+		return &x.Sat
+	case "hdop":
+		return &x.HDOP
+	case "vdop":
+		return &x.VDOP
+	case "pdop":
+		return &x.PDOP
+	case "hr":
+		return &x.HR
+	case "cad":
+		return &x.Cad
+	case "power", "PowerInWatts":
+		return &x.Power
+	}
+	return nil
+}
+
+/*
+parseExtra returns the optional TrkptExtra data of track point slice b,
+nil if b has none. Empty elements, e.g. <hdop/> or <gpxtpx:hr></gpxtpx:hr>,
+are missing values. All elements of b are scanned once, which costs little
+for the usual track point with only <ele> and <time> elements.
+*/
+func parseExtra(b []byte) (*TrkptExtra, error) {
+	var x TrkptExtra
+
+	found := false
+	for {
+		i := indexByte(b, '<')
+		if i < 0 {
+			break
+		}
+		b = b[i+1:]
+		n := 0
+		for n < len(b) && b[n] > ' ' && b[n] != '>' && b[n] != '/' {
+			n++
+		}
+		name := b[:n]
+		if k := indexByte(name, ':'); k >= 0 { //drop namespace prefix
+			name = name[k+1:]
+		}
+		f := x.field(string(name))
+		if f == nil {
+			continue
+		}
+		l := indexByte(b[n:], '>') + n + 1
+		if l <= n {
+			return nil, ErrSyntax
+		}
+		if b[l-2] == '/' { //empty <hdop/> is missing
+			b = b[l:]
+			continue
+		}
+		r := indexByte(b[l:], '<') + l
+		if r < l {
+			return nil, ErrSyntax
+		}
+		text := trimSpace(b[l:r])
+		b = b[r:]
+		if len(text) == 0 { //empty <hdop></hdop> is missing
+			continue
+		}
+		if !found {
+			x, found = newTrkptExtra(), true
+		}
+		v, e := parseFloat(text)
+		if e != nil {
+			return nil, ErrInvalidExtra
+		}
+		*f = v
+	}
+	if !found {
+		return nil, nil
+	}
+	p := new(TrkptExtra)
+	*p = x
+	return p, nil
+}
+
+// trkptExtraXML has the TrkptExtra elements for encoding/xml decoding.
+// The values are strings, as encoding/xml decodes an empty element as 0.
+type trkptExtraXML struct {
+	MagVar      *string `xml:"magvar"`
+	GeoidHeight *string `xml:"geoidheight"`
+	Sat         *string `xml:"sat"`
+	HDOP        *string `xml:"hdop"`
+	VDOP        *string `xml:"vdop"`
+	PDOP        *string `xml:"pdop"`
+	HR          *string `xml:"extensions>TrackPointExtension>hr"`
+	Cad         *string `xml:"extensions>TrackPointExtension>cad"`
+	Power       *string `xml:"extensions>power"`
+}
+
+// extra returns the TrkptExtra of the decoded elements, nil if there are
+// none. Empty elements are missing values as in ParseGPX.
+func (t *trkptExtraXML) extra() (*TrkptExtra, error) {
+	found := false
+	x := newTrkptExtra()
+	for _, f := range []struct {
+		v   *string
+		dst *float64
+	}{
+		{t.MagVar, &x.MagVar},
+		{t.GeoidHeight, &x.GeoidHeight},
+		{t.Sat, &x.Sat},
+		{t.HDOP, &x.HDOP},
+		{t.VDOP, &x.VDOP},
+		{t.PDOP, &x.PDOP},
+		{t.HR, &x.HR},
+		{t.Cad, &x.Cad},
+		{t.Power, &x.Power},
+	} {
+		if f.v == nil || strings.TrimSpace(*f.v) == "" {
+			continue
+		}
+		v, e := strconv.ParseFloat(strings.TrimSpace(*f.v), 64)
+		if e != nil {
+			return nil, ErrInvalidExtra
+		}
+		*f.dst = v
+		found = true
+	}
+	if !found {
+		return nil, nil
+	}
+	return &x, nil
+}
@@ -0,0 +1,180 @@
+package gpx
+
+// Filter keeps the track points of the first track segment, for which
+// keep returns true, in place and returns the count of removed points.
+// The order of the kept points is not changed and nothing is allocated.
+func (gpx *GPX) Filter(keep func(Trkpt) bool) int {
+	s := gpx.firstTrkpts()
+	n := 0
+	for _, p := range s {
+		if keep(p) {
+			s[n] = p
+			n++
+		}
+	}
+	if n < len(s) {
+		clear(s[n:])
+		gpx.Trks[0].Trksegs[0].Trkpts = s[:n]
+	}
+	return len(s) - n
+}
+
+// ClipToBounds removes track points outside the bounding box from the
+// first track segment in place and returns the count of removed points.
+// The track is not split where it leaves and re-enters the box, the
+// outside points are simply dropped.
+func (gpx *GPX) ClipToBounds(minLat, minLon, maxLat, maxLon float64) int {
+	b := Bounds{minLat, minLon, maxLat, maxLon}
+	return gpx.Filter(b.Contains)
+}
+
+/*
+RemoveSpikes removes GPS spikes from the first track segment in place
+and returns the count of removed points. A point is a spike, if the
+speed both from the previous kept point to it and from it to the next
+point is over maxSpeed m/s. As the previous point is the last kept one,
+the gap left by a removed spike is checked again with the next point.
+Points without time are never spikes. The first and last points are kept.
+*/
+func (gpx *GPX) RemoveSpikes(maxSpeed float64) int {
+	return gpx.removeSpikes(func(p, q Trkpt) bool {
+		if p.Time.IsZero() || q.Time.IsZero() {
+			return false
+		}
+		d := dist(p, q)
+		dt := q.Time.Sub(p.Time).Seconds()
+		if dt <= 0 {
+			return d > 0
+		}
+		return d > maxSpeed*dt
+	})
+}
+
+// RemoveJumps is like RemoveSpikes without time: a point is a spike, if
+// it is over maxJumpMeters from both the previous kept and the next point.
+func (gpx *GPX) RemoveJumps(maxJumpMeters float64) int {
+	return gpx.removeSpikes(func(p, q Trkpt) bool {
+		return dist(p, q) > maxJumpMeters
+	})
+}
+
+// removeSpikes removes the points of the first track segment, which
+// jump from both the previous kept and the next point.
+func (gpx *GPX) removeSpikes(jump func(p, q Trkpt) bool) int {
+	s := gpx.firstTrkpts()
+	if len(s) < 3 {
+		return 0
+	}
+	n := 1
+	for i := 1; i < len(s)-1; i++ {
+		if jump(s[n-1], s[i]) && jump(s[i], s[i+1]) {
+			continue
+		}
+		s[n] = s[i]
+		n++
+	}
+	s[n] = s[len(s)-1]
+	n++
+	clear(s[n:])
+	gpx.Trks[0].Trksegs[0].Trkpts = s[:n]
+	return len(s) - n
+}
+
+// RemoveDuplicates removes the track points of the first track segment,
+// which have the same lat, lon and time as the previous point, e.g. of a
+// logger writing a point twice, in place and returns their count.
+func (gpx *GPX) RemoveDuplicates() int {
+	s := gpx.firstTrkpts()
+	if len(s) < 2 {
+		return 0
+	}
+	n := 1
+	for _, p := range s[1:] {
+		if q := s[n-1]; p.Lat == q.Lat && p.Lon == q.Lon && p.Time.Equal(q.Time) {
+			continue
+		}
+		s[n] = p
+		n++
+	}
+	clear(s[n:])
+	gpx.Trks[0].Trksegs[0].Trkpts = s[:n]
+	return len(s) - n
+}
+
+/*
+TrimStationary removes the leading and trailing stationary points, e.g.
+standing still before the start, from the first track segment in place
+and returns their counts. A point pair is stationary, if the speed
+between them is under minSpeed m/s. A pair with a point without time
+is not stationary, see TrimStationaryDistance for tracks without time.
+The interior is kept as it is. If no pair is moving, nothing is removed.
+*/
+func (gpx *GPX) TrimStationary(minSpeed float64) (head, tail int) {
+	moving := func(p, q Trkpt) bool {
+		if p.Time.IsZero() || q.Time.IsZero() {
+			return true
+		}
+		d := dist(p, q)
+		dt := q.Time.Sub(p.Time).Seconds()
+		if dt <= 0 {
+			return d > 0
+		}
+		return d >= minSpeed*dt
+	}
+	s := gpx.firstTrkpts()
+	i, j := 0, len(s)-1
+	for i < j && !moving(s[i], s[i+1]) {
+		i++
+	}
+	for j > i && !moving(s[j-1], s[j]) {
+		j--
+	}
+	if i == j {
+		return 0, 0
+	}
+	return gpx.trim(i, len(s)-1-j)
+}
+
+/*
+TrimStationaryDistance is TrimStationary without time: the leading points
+within minMoveMeters of the first point and the trailing points within
+minMoveMeters of the last point are removed, except the one closest to
+the moving part. If all points are within minMoveMeters of the first or
+the last point, nothing is removed.
+*/
+func (gpx *GPX) TrimStationaryDistance(minMoveMeters float64) (head, tail int) {
+	s := gpx.firstTrkpts()
+	if len(s) < 2 {
+		return 0, 0
+	}
+	i, j := 0, len(s)-1
+	for i < len(s)-1 && dist(s[0], s[i+1]) < minMoveMeters {
+		i++
+	}
+	for j > 0 && dist(s[len(s)-1], s[j-1]) < minMoveMeters {
+		j--
+	}
+	if i >= j {
+		return 0, 0
+	}
+	return gpx.trim(i, len(s)-1-j)
+}
+
+// trim removes head leading and tail trailing points of the first track
+// segment in place and returns head and tail.
+func (gpx *GPX) trim(head, tail int) (int, int) {
+	s := gpx.firstTrkpts()
+	n := copy(s, s[head:len(s)-tail])
+	clear(s[n:])
+	gpx.Trks[0].Trksegs[0].Trkpts = s[:n]
+	return head, tail
+}
+
+// FilterByHDOP removes the track points with <hdop> over max from the
+// first track segment in place and returns the count of removed points.
+// Track points without hdop are kept.
+func (gpx *GPX) FilterByHDOP(max float64) int {
+	return gpx.Filter(func(p Trkpt) bool {
+		return p.Extra == nil || !(p.Extra.HDOP > max) //NaN is not
+	})
+}
@@ -0,0 +1,264 @@
+package gpx
+
+import "math"
+
+// Bounds is a latitude-longitude bounding box.
+type Bounds struct {
+	MinLat, MinLon, MaxLat, MaxLon float64
+}
+
+// Contains reports whether p is inside or on the edge of bounds b.
+func (b Bounds) Contains(p Trkpt) bool {
+	return p.Lat >= b.MinLat && p.Lat <= b.MaxLat &&
+		p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+}
+
+// Bounds returns the bounding box of the first track segment. Track
+// points without valid lat and lon, see Marshal, are ignored. Bounds of
+// an empty track is the zero Bounds.
+func (gpx *GPX) Bounds() Bounds {
+	b, _ := boundsOf(Bounds{}, false, gpx.firstTrkpts())
+	return b
+}
+
+// allBounds returns the bounding box of all track points of gpx,
+// false if there are none.
+func (gpx *GPX) allBounds() (b Bounds, ok bool) {
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			b, ok = boundsOf(b, ok, seg.Trkpts)
+		}
+	}
+	return b, ok
+}
+
+// boundsOf returns bounds b extended by track points s. If ok is false,
+// b is empty and it is replaced by the bounds of s, if s has a point
+// with valid lat and lon.
+func boundsOf(b Bounds, ok bool, s []Trkpt) (Bounds, bool) {
+	for _, p := range s {
+		if !hasCoords(p) {
+			continue
+		}
+		if !ok {
+			b, ok = Bounds{p.Lat, p.Lon, p.Lat, p.Lon}, true
+		}
+		b.MinLat = min(b.MinLat, p.Lat)
+		b.MaxLat = max(b.MaxLat, p.Lat)
+		b.MinLon = min(b.MinLon, p.Lon)
+		b.MaxLon = max(b.MaxLon, p.Lon)
+	}
+	return b, ok
+}
+
+// EarthRadius is the Earth radius in meters used in spherical distance
+// computations. The default is the mean Earth radius.
+var EarthRadius = 6371000.0
+
+// DistanceUnit is a length unit as its length in meters.
+type DistanceUnit float64
+
+const (
+	Meters        DistanceUnit = 1
+	Kilometers    DistanceUnit = 1000
+	Miles         DistanceUnit = 1609.344
+	NauticalMiles DistanceUnit = 1852
+	Feet          DistanceUnit = 0.3048
+)
+
+// FromMeters converts distance m in meters to unit u.
+func (u DistanceUnit) FromMeters(m float64) float64 {
+	return m / float64(u)
+}
+
+// Haversine returns the great-circle distance in meters between points
+// (lat1, lon1) and (lat2, lon2) given in degrees on a sphere of EarthRadius.
+func Haversine(lat1, lon1, lat2, lon2 float64) float64 {
+	const rad = math.Pi / 180
+	dlat := (lat2 - lat1) * rad
+	dlon := (lon2 - lon1) * rad
+	a := math.Sin(dlat/2)*math.Sin(dlat/2) +
+		math.Cos(lat1*rad)*math.Cos(lat2*rad)*math.Sin(dlon/2)*math.Sin(dlon/2)
+	return 2 * EarthRadius * math.Asin(math.Sqrt(min(a, 1)))
+}
+
+// lonDelta returns lon2 - lon1 in degrees normalized to [-180, 180),
+// the shorter way around also across the antimeridian.
+func lonDelta(lon1, lon2 float64) float64 {
+	d := math.Mod(lon2-lon1+180, 360)
+	if d < 0 {
+		d += 360
+	}
+	return d - 180
+}
+
+// normLon returns longitude lon normalized to [-180, 180).
+func normLon(lon float64) float64 {
+	return lonDelta(0, lon)
+}
+
+// dist returns the haversine distance in meters between track points p and q.
+func dist(p, q Trkpt) float64 {
+	return Haversine(p.Lat, p.Lon, q.Lat, q.Lon)
+}
+
+// Distance returns the haversine length of the first track segment in meters.
+// Elevation differences are not included.
+func (gpx *GPX) Distance() float64 {
+	return pathLength(gpx.firstTrkpts(), dist)
+}
+
+// pathLength returns the length of the path through track points s
+// with distance function d between consecutive points.
+func pathLength(s []Trkpt, d func(p, q Trkpt) float64) float64 {
+	l := 0.0
+	for i := 1; i < len(s); i++ {
+		l += d(s[i-1], s[i])
+	}
+	return l
+}
+
+/*
+DistanceFiltered returns the haversine length of the first track segment
+in meters without the track points with <hdop> over maxHDOP, e.g. bad
+fixes in urban canyons. A skipped point is bridged by the straight
+distance from the previous kept point to the next kept one, so the track
+is not cut. Leading and trailing skipped points are not included. Track
+points without hdop are kept as in FilterByHDOP. gpx is not changed.
+*/
+func (gpx *GPX) DistanceFiltered(maxHDOP float64) float64 {
+	s := gpx.firstTrkpts()
+	prev := -1 //index of the previous kept point
+	l := 0.0
+	for i, p := range s {
+		if p.Extra != nil && p.Extra.HDOP > maxHDOP { //NaN is not
+			continue
+		}
+		if prev >= 0 {
+			l += dist(s[prev], p)
+		}
+		prev = i
+	}
+	return l
+}
+
+// DistanceIn returns the haversine length of the first track segment in unit u.
+func (gpx *GPX) DistanceIn(u DistanceUnit) float64 {
+	return u.FromMeters(gpx.Distance())
+}
+
+// CumulativeDistances returns the haversine distance in meters from the
+// first track point to each track point of the first track segment.
+func (gpx *GPX) CumulativeDistances() []float64 {
+	return cumDistances(gpx.firstTrkpts())
+}
+
+// CumulativeDistancesIn is CumulativeDistances in unit u.
+func (gpx *GPX) CumulativeDistancesIn(u DistanceUnit) []float64 {
+	cum := gpx.CumulativeDistances()
+	for i := range cum {
+		cum[i] = u.FromMeters(cum[i])
+	}
+	return cum
+}
+
+// cumDistances returns cumulative distances along track points s.
+func cumDistances(s []Trkpt) []float64 {
+	if len(s) == 0 {
+		return nil
+	}
+	cum := make([]float64, len(s))
+	for i := 1; i < len(s); i++ {
+		cum[i] = cum[i-1] + dist(s[i-1], s[i])
+	}
+	return cum
+}
+
+// MedianSpacing returns the median haversine distance in meters between
+// consecutive track points of the first track segment, 0 if there are
+// less than two track points.
+func (gpx *GPX) MedianSpacing() float64 {
+	s := gpx.firstTrkpts()
+	if len(s) < 2 {
+		return 0
+	}
+	d := make([]float64, len(s)-1)
+	for i := 1; i < len(s); i++ {
+		d[i-1] = dist(s[i-1], s[i])
+	}
+	return medianOf(d)
+}
+
+/*
+Bearings returns the initial great-circle bearing in degrees [0, 360),
+clockwise from north, from each track point of the first track segment
+to the next one. The last point repeats the bearing of the previous one,
+so that each point has a heading, e.g. for arrows on a map. A single
+point has bearing NaN and an empty segment gives nil.
+*/
+func (gpx *GPX) Bearings() []float64 {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return nil
+	}
+	b := make([]float64, len(s))
+	for i := 0; i < len(s)-1; i++ {
+		b[i] = bearing(s[i], s[i+1])
+	}
+	b[len(s)-1] = math.NaN()
+	if len(s) > 1 {
+		b[len(s)-1] = b[len(s)-2]
+	}
+	return b
+}
+
+// bearing returns the initial great-circle bearing in degrees [0, 360)
+// from p to q.
+func bearing(p, q Trkpt) float64 {
+	const rad = math.Pi / 180
+	lat1, lat2 := p.Lat*rad, q.Lat*rad
+	dlon := lonDelta(p.Lon, q.Lon) * rad
+	y := math.Sin(dlon) * math.Cos(lat2)
+	x := math.Cos(lat1)*math.Sin(lat2) - math.Sin(lat1)*math.Cos(lat2)*math.Cos(dlon)
+	return math.Mod(math.Atan2(y, x)/rad+360, 360)
+}
+
+/*
+Centroid returns the arithmetic mean of lat and lon of the track points
+of the first track segment. This is fine for a label position of a track
+of some hundred kilometers, but the mean is wrong for tracks crossing the
+antimeridian or near a pole, see GeographicMidpoint. For an empty track
+lat and lon are NaN.
+*/
+func (gpx *GPX) Centroid() (lat, lon float64) {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return math.NaN(), math.NaN()
+	}
+	for _, p := range s {
+		lat += p.Lat
+		lon += p.Lon
+	}
+	return lat / float64(len(s)), lon / float64(len(s))
+}
+
+/*
+GeographicMidpoint returns the geographic midpoint of the track points of
+the first track segment, the mean of their unit vectors from the earth
+center projected back to the sphere. Unlike Centroid, it is correct also
+across the antimeridian and near the poles. For an empty track lat and
+lon are NaN.
+*/
+func (gpx *GPX) GeographicMidpoint() (lat, lon float64) {
+	const rad = math.Pi / 180
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return math.NaN(), math.NaN()
+	}
+	var x, y, z float64
+	for _, p := range s {
+		px, py, pz := unitVector(p)
+		x, y, z = x+px, y+py, z+pz
+	}
+	return math.Atan2(z, math.Hypot(x, y)) / rad, math.Atan2(y, x) / rad
+}
@@ -0,0 +1,34 @@
+package gpx
+
+import (
+	"math"
+	"testing"
+)
+
+func TestAntimeridian(t *testing.T) {
+	gpx := parseTestdata(t, "antimeridian.gpx")
+	want := 0.002 * math.Pi / 180 * EarthRadius * math.Cos(17*math.Pi/180)
+	tests := []struct {
+		name string
+		got  float64
+		tol  float64
+	}{
+		{"Distance", gpx.Distance(), 0.01},
+		{"DistanceVincenty", gpx.DistanceVincenty(), 0.01},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-want) > tt.tol*want {
+			t.Errorf("%s = %.2f, want %.2f", tt.name, tt.got, want)
+		}
+	}
+	if _, ok := vincenty(-17, 179.9995, -17, -179.9995); !ok {
+		t.Error("vincenty does not converge across the antimeridian")
+	}
+	_, along, perp := gpx.SnapToTrack(-17.00001, -180)
+	if math.Abs(along-want/2) > 1 || perp > 2 {
+		t.Errorf("SnapToTrack: along %.2f, perp %.2f, want %.2f, 0", along, perp, want/2)
+	}
+	if n := gpx.Simplify(1); n != 2 {
+		t.Errorf("Simplify removed %d points, want 2", n)
+	}
+}
@@ -0,0 +1,23 @@
+package gpx
+
+/*
+CrossingsOf returns the indices of the track points of the first track
+segment, where the track enters or exits the circle of radius meters
+around (lat, lon). Index i is a crossing, if point i is inside and point
+i-1 outside the circle or vice versa. If the track starts inside the
+circle, index 0 is the first entry. So entries and exits alternate,
+starting with an entry. A point on the circle is inside.
+*/
+func (gpx *GPX) CrossingsOf(lat, lon, radius float64) []int {
+	var idx []int
+
+	inside := false
+	for i, p := range gpx.firstTrkpts() {
+		in := Haversine(lat, lon, p.Lat, p.Lon) <= radius
+		if in != inside {
+			idx = append(idx, i)
+			inside = in
+		}
+	}
+	return idx
+}
@@ -0,0 +1,68 @@
+package gpx
+
+import (
+	"encoding/json"
+	"io"
+)
+
+/*
+WriteGeoJSON writes gpx to w as a GeoJSON FeatureCollection with a
+MultiLineString Feature for each track, a line for each track segment.
+Positions are [lon, lat, ele], or [lon, lat] if elevation is missing.
+The track name is the "name" property. Times are not written. Points
+without valid lat and lon are skipped as in Marshal. Numbers are
+formatted by DefaultWriteOptions.
+*/
+func (gpx *GPX) WriteGeoJSON(w io.Writer) error {
+	return gpx.WriteGeoJSONWithOptions(w, DefaultWriteOptions)
+}
+
+// WriteGeoJSONWithOptions is like WriteGeoJSON, but numbers are formatted by opts.
+func (gpx *GPX) WriteGeoJSONWithOptions(w io.Writer, opts WriteOptions) error {
+	bw := &bufWriter{w: w, b: make([]byte, 0, writeBufSize)}
+	bw.b = append(bw.b, `{"type":"FeatureCollection","features":[`...)
+	for i, trk := range gpx.Trks {
+		if i > 0 {
+			bw.b = append(bw.b, ',')
+		}
+		name, _ := json.Marshal(trk.Name)
+		bw.b = append(bw.b, "\n"+`{"type":"Feature","properties":{"name":`...)
+		bw.b = append(bw.b, name...)
+		bw.b = append(bw.b, `},"geometry":{"type":"MultiLineString","coordinates":[`...)
+		for j, seg := range trk.Trksegs {
+			if j > 0 {
+				bw.b = append(bw.b, ',')
+			}
+			bw.b = append(bw.b, '[')
+			sep := false
+			for _, p := range seg.Trkpts {
+				if !hasCoords(p) {
+					continue
+				}
+				if sep {
+					bw.b = append(bw.b, ',')
+				}
+				sep = true
+				bw.b = appendPosition(bw.b, p, opts)
+				bw.maybeFlush()
+			}
+			bw.b = append(bw.b, ']')
+		}
+		bw.b = append(bw.b, "]}}"...)
+	}
+	bw.b = append(bw.b, "\n]}\n"...)
+	return bw.flush()
+}
+
+// appendPosition appends track point p as a GeoJSON position to b.
+func appendPosition(b []byte, p Trkpt, opts WriteOptions) []byte {
+	b = append(b, '[')
+	b = appendFloat(b, p.Lon, opts.CoordDecimals)
+	b = append(b, ',')
+	b = appendFloat(b, p.Lat, opts.CoordDecimals)
+	if p.HasEle() {
+		b = append(b, ',')
+		b = appendFloat(b, p.Ele, opts.EleDecimals)
+	}
+	return append(b, ']')
+}
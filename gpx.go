@@ -2,121 +2,744 @@ package gpx
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt" //errf
+	"io"
+	"io/fs"
+	"math"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/pekkizen/numconv"
 )
 
+/*
+GPX is a parsed GPX file. Methods, which do not modify gpx, keep no
+lazily computed state, so they are safe for concurrent use by many
+goroutines, when no goroutine modifies gpx. Modifying methods are the
+ones documented to work in place, e.g. ClipToBounds, and AddPoint,
+Append, Compact, Consume, MergeSegments, Release, Reset, SplitByTimeGap
+and TrkpSliceRelease, as are the parse functions filling gpx. Use Clone
+to get a private copy to modify.
+
+TrkpSlice and SplitByDistance return slices aliasing the track points of
+gpx: modifying them modifies gpx and appending to them may overwrite
+following track points. Their copying variants, TrkpSliceCopy and
+SplitByDistanceCopy, return deep copies, Extra values included. Other
+methods returning track points return new slices, unless documented
+otherwise.
+*/
 type GPX struct {
-	Creator string `xml:"creator,attr"`
-	Version string `xml:"version,attr"`
-	Time    string `xml:"time"`
-	Trks    []Trk  `xml:"trk"`
-	errcnt  int
+	Creator  string   `xml:"creator,attr" json:"creator,omitempty"`
+	Version  string   `xml:"version,attr" json:"version,omitempty"`
+	Time     string   `xml:"time" json:"time,omitempty"`
+	Metadata Metadata `xml:"metadata" json:"metadata"`
+	Trks     []Trk    `xml:"trk" json:"trks"`
+	errcnt   int
+	warncnt  int           //track points with a NaN coordinate, see PartialCoordinates
+	estimate int           //initial track point capacity of the fast parser
+	offsets  []int         //<trkpt offsets, see TrackOffsets
+	offsOpts *ParseOptions //options of the parse of offsets, see MarshalLossless
+}
+
+// Metadata has optional fields of the GPX <metadata> element.
+// Missing fields are empty.
+type Metadata struct {
+	Author   string `xml:"author>name" json:"author,omitempty"`
+	Link     Link   `xml:"link" json:"link"`
+	Keywords string `xml:"keywords" json:"keywords,omitempty"`
+}
+
+// Link is a GPX <link> element.
+type Link struct {
+	Href string `xml:"href,attr" json:"href,omitempty"`
+	Text string `xml:"text" json:"text,omitempty"`
 }
 type Trk struct {
-	Name    string   `xml:"name"`
-	Trksegs []Trkseg `xml:"trkseg"`
+	Name    string   `xml:"name" json:"name,omitempty"`
+	Cmt     string   `xml:"cmt" json:"cmt,omitempty"`   // comment
+	Desc    string   `xml:"desc" json:"desc,omitempty"` // description
+	Trksegs []Trkseg `xml:"trkseg" json:"trksegs"`
 }
 type Trkseg struct {
-	Trkpts []Trkpt `xml:"trkpt"`
+	Trkpts []Trkpt `xml:"trkpt" json:"trkpts"`
 }
 type Trkpt struct {
-	Lat float64 `xml:"lat,attr"`
-	Lon float64 `xml:"lon,attr"`
-	Ele float64 `xml:"ele"`
+	Lat   float64     `xml:"lat,attr" json:"lat"`
+	Lon   float64     `xml:"lon,attr" json:"lon"`
+	Ele   float64     `xml:"ele" json:"ele"`   // NaN if the track point has no elevation
+	Time  time.Time   `xml:"time" json:"time"` // zero if the track point has no time
+	Extra *TrkptExtra `xml:"-" json:"-"`       // nil if the track point has no extra data
+}
+
+// ParseOptions controls parsing in NewWithOptions and ParseGPXWithOptions.
+// The zero value parses as ParseGPX without ignoring errors, so a track
+// point without elevation is an error.
+type ParseOptions struct {
+	UseXMLParser          bool // use encoding/xml.Unmarshal instead of ParseGPX
+	IgnoreErrors          bool // drop track points with errors, see ErrCount
+	AllowMissingElevation bool // accept track points without <ele>, Ele is NaN, fast parser only
+	DecimalComma          bool // accept 615,25 for 615.25, non-standard, fast parser only
+	StrictXML             bool // check that all elements are balanced, see CheckXML
+	Tags                  Tags // custom tag names of the fast parser
+
+	// PartialCoordinates keeps a track point with only one of lat and lon
+	// valid, the other one is NaN and counted in WarnCount. Fast parser only.
+	PartialCoordinates bool
+
+	// TrackOffsets records the byte offset of each <trkpt in the data,
+	// see Offsets. Fast parser only.
+	TrackOffsets bool
+
+	// ElevationUnit is the unit of <ele> values, converted to meters,
+	// e.g. Feet. Zero is Meters. This is a workaround for non-conformant
+	// files, GPX elevations are meters.
+	ElevationUnit DistanceUnit
+
+	// MaxBytes is the maximum size of GPX data in bytes, if > 0. Larger
+	// data is ErrTooLarge, checked before reading a file. For compressed
+	// data the limit is on the decompressed size.
+	MaxBytes int
+}
+
+/*
+Tags are custom tag names of the fast parser for non-standard GPX-like
+data, e.g. Ele "<gpx:ele>". Empty tags are the standard "<trkpt", "<ele>"
+and "<time>". A Trkpt tag has no closing '>', as it is followed by
+attributes, and its closing tag is derived from it, e.g. "</gpx:trkpt>".
+Tags must start with '<', otherwise ErrInvalidTag is returned.
+Namespace prefixed standard tags, e.g. <ns2:trkpt, are detected without
+custom tags.
+*/
+type Tags struct {
+	Trkpt string
+	Ele   string
+	Time  string
+}
+
+// parser holds the state of a single ParseGPX run.
+type parser struct {
+	opts        ParseOptions
+	ctx         context.Context //nil if parsing is not cancelable
+	trkpLen     int             //estimate lenght of a track point slice in bytes
+	startSearch int             //index from where to start searching for </trkpt>
+	pointHint   int             //initial track point capacity if > 0
+	limit       int             //maximum number of track points if > 0
+	data        []byte          //all GPX data, for error positions
+	rest        []byte          //data after the last track point
+	retries     int             //count of missed closing tag searches
+	warncnt     int             //count of partial coordinates
+	offsets     []int           //track point offsets if opts.TrackOffsets
+	progress    func(int)       //nil or called every ctxCheckInterval track points
+	buf         []byte          //track point copy for DecimalComma
+	starttag    []byte          //track point start tag, see Tags
+	closetag    []byte
+	eletag      []byte
+	timetag     []byte
+}
+
+// ParseStats are parser metrics of ParseGPXStats.
+type ParseStats struct {
+	Trkpts  int // valid track points
+	Errors  int // ignored track point errors
+	Retries int // </trkpt> searches started too far, see nextTrkpt
+	Bytes   int // bytes of data up to the end of the last track point
 }
 
 const (
-	quotemark       = '"'   // '\'' (single quote) is also accepted by XML parser
-	use_std_library = false //for ParseFloat, TrimSpace, Index and IndexByte, testing
+	use_std_library  = false //for ParseFloat and the default SearchStrategy, testing
+	ctxCheckInterval = 4096  //track points between context checks and progress calls
+)
+
+// SearchStrategy selects the byte search functions of the fast parser.
+type SearchStrategy int
+
+const (
+	// SearchCustom uses the parser's own loops, the default. They tend to
+	// win, when the searched tags are short and near, as in the usual
+	// track points with only <ele> and <time>.
+	SearchCustom SearchStrategy = iota
+
+	// SearchStdlib uses bytes.Index and bytes.IndexByte, which are SIMD
+	// accelerated on many platforms. They tend to win, when the tags are
+	// far apart, e.g. after long <extensions>.
+	SearchStdlib
+)
+
+var stdSearch = use_std_library //SearchStdlib is selected
+
+/*
+SetSearchStrategy selects the byte search functions of the fast parser
+for all later parsing, e.g. to compare them by BenchmarkCompare on a
+platform without recompiling. The results of parsing usual GPX data are
+the same. It must not be called, while GPX data is parsed.
+*/
+func SetSearchStrategy(s SearchStrategy) {
+	stdSearch = s == SearchStdlib
+}
+
+var (
+	latname  = []byte("lat")
+	lonname  = []byte("lon")
+	eletag   = []byte("<ele>")
+	timetag  = []byte("<time>")
+	starttag = []byte("<trkpt")
+	closetag = []byte("</trkpt>")
+	bom      = []byte("\xef\xbb\xbf")
+	errf     = fmt.Errorf
 )
 
+// Parse errors. Use errors.Is to test for them. Track point errors are
+// allocation free for ignored errors. Returned track point errors wrap
+// them with the track point number and data.
 var (
-	latname     = []byte("lat")
-	lonname     = []byte("lon")
-	eletag      = []byte("<ele>")
-	starttag    = []byte("<trkpt")
-	closetag    = []byte("</trkpt>")
-	trkpLen     int //estimate lenght of a track point slice in bytes
-	startSearch int //index from where to start searching for </trkpt>
-	errf        = fmt.Errorf
+	ErrNoTrackpoints     = errors.New("no valid track points found")
+	ErrMissingLatitude   = errors.New("missing lat attribute")
+	ErrMissingLongitude  = errors.New("missing lon attribute")
+	ErrMissingElevation  = errors.New("missing elevation tag")
+	ErrInvalidCoordinate = errors.New("invalid lat or lon value")
+	ErrInvalidElevation  = errors.New("invalid elevation value")
+	ErrInvalidTime       = errors.New("invalid time value")
+	ErrInvalidExtra      = errors.New("invalid track point element value")
+	ErrSyntax            = errors.New("invalid track point syntax")
+	ErrUnbalanced        = errors.New("unbalanced <trkpt> and </trkpt> tags")
+	ErrInvalidTag        = errors.New("custom tag does not start with <")
+	ErrMalformedXML      = errors.New("malformed XML")
+	ErrTooLarge          = errors.New("GPX data exceeds MaxBytes")
 )
 
 // New returns a GPX struct with parsed latitude, longitude and elevation data from gpxFileName.
+// Track points without elevation are errors.
 func New(gpxFileName string, useXMLparser, ignoreErrors bool) (*GPX, error) {
+	return NewWithOptions(gpxFileName, ParseOptions{
+		UseXMLParser: useXMLparser,
+		IgnoreErrors: ignoreErrors,
+	})
+}
 
-	gpx := &GPX{}
+// NewWithOptions is like New, but parsing is controlled by opts. Zero
+// opts parse as New without the XML parser and ignoring errors.
+func NewWithOptions(gpxFileName string, opts ParseOptions) (*GPX, error) {
+	if fi, e := os.Stat(gpxFileName); e == nil && opts.tooLarge(fi.Size()) {
+		return &GPX{}, errf("%s: %w", gpxFileName, ErrTooLarge)
+	}
 	gpxbytes, e := os.ReadFile(gpxFileName)
 	if e != nil {
-		return gpx, errf("%v", e)
+		return &GPX{}, errf("%w", e)
+	}
+	return newGPX(gpxFileName, gpxbytes, opts)
+}
+
+// NewFS is like NewWithOptions, but the file name is read from fsys,
+// e.g. an embed.FS or a zip.Reader.
+func NewFS(fsys fs.FS, name string, opts ParseOptions) (*GPX, error) {
+	if fi, e := fs.Stat(fsys, name); e == nil && opts.tooLarge(fi.Size()) {
+		return &GPX{}, errf("%s: %w", name, ErrTooLarge)
+	}
+	gpxbytes, e := fs.ReadFile(fsys, name)
+	if e != nil {
+		return &GPX{}, errf("%w", e)
+	}
+	return newGPX(name, gpxbytes, opts)
+}
+
+// NewReader is like NewWithOptions, but GPX data is read from r,
+// e.g. an already open *os.File, until EOF. With MaxBytes at most
+// MaxBytes+1 bytes are read.
+func NewReader(r io.Reader, opts ParseOptions) (*GPX, error) {
+	if opts.MaxBytes > 0 {
+		r = io.LimitReader(r, int64(opts.MaxBytes)+1)
+	}
+	gpxbytes, e := io.ReadAll(r)
+	if e != nil {
+		return &GPX{}, errf("%w", e)
 	}
-	if useXMLparser {
-		e = xml.Unmarshal(gpxbytes, gpx)
+	return newGPX("", gpxbytes, opts)
+}
+
+// Parse is like NewWithOptions, but GPX data is gpxbytes, e.g. an
+// uploaded file. ParseGPX reuses an existing GPX instead.
+func Parse(gpxbytes []byte, opts ParseOptions) (*GPX, error) {
+	return newGPX("", gpxbytes, opts)
+}
+
+// newGPX parses gpxbytes by the parser selected in opts. Errors are
+// prefixed by name, if it is not empty.
+func newGPX(name string, gpxbytes []byte, opts ParseOptions) (*GPX, error) {
+	var e error
+
+	gpx := &GPX{}
+	if opts.tooLarge(int64(len(gpxbytes))) {
+		e = ErrTooLarge
+	} else if opts.UseXMLParser {
+		gpxbytes = trimBOM(gpxbytes)
+		root := gpxRoot(gpxbytes)
+		r := io.Reader(bytes.NewReader(root))
+		if decl := xmlDecl(gpxbytes); decl != nil && len(root) < len(gpxbytes) {
+			r = io.MultiReader(bytes.NewReader(decl), r) //keep the encoding
+		}
+		d := xml.NewDecoder(r)
+		d.CharsetReader = charsetReader
+		if e = d.Decode(gpx); e != nil {
+			gpx.Trks = nil //as in ParseGPX
+		} else if l := bytes.Index(root, starttag); l >= 0 {
+			gpx.parseHeader(textUTF8(gpxbytes, root[:l])) //GPX 1.0 and 1.1 layouts as in ParseGPX
+		}
+		if u := opts.ElevationUnit; u != 0 {
+			for _, trk := range gpx.Trks {
+				for _, seg := range trk.Trksegs {
+					for i := range seg.Trkpts {
+						seg.Trkpts[i].Ele *= float64(u)
+					}
+				}
+			}
+		}
 	} else {
 		// this is 30 x faster
-		e = ParseGPX(gpxbytes, gpx, ignoreErrors)
+		e = ParseGPXWithOptions(gpxbytes, gpx, opts)
+	}
+	if e != nil && name != "" {
+		return gpx, errf("%s: %w", name, e)
+	}
+	return gpx, e
+}
+
+// tooLarge reports whether size bytes exceeds opts.MaxBytes.
+func (opts ParseOptions) tooLarge(size int64) bool {
+	return opts.MaxBytes > 0 && size > int64(opts.MaxBytes)
+}
+
+// UnmarshalXML decodes a track point for encoding/xml. Ele of a
+// track point without <ele> element or with an empty, NaN or Inf one is
+// set to NaN and Extra is set as in ParseGPX.
+func (p *Trkpt) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	type trkpt Trkpt // trkpt has no UnmarshalXML method
+	var t struct {
+		trkpt
+		Ele *string `xml:"ele"` // hides trkpt.Ele, encoding/xml decodes <ele/> as 0
+		trkptExtraXML
+	}
+	if e := d.DecodeElement(&t, &start); e != nil {
+		return e
+	}
+	for _, f := range []float64{t.Lat, t.Lon} {
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return ErrInvalidCoordinate
+		}
+	}
+	t.trkpt.Ele = math.NaN()
+	if t.Ele != nil {
+		if ele := strings.TrimSpace(*t.Ele); ele != "" {
+			f, e := strconv.ParseFloat(ele, 64)
+			if e != nil {
+				return e
+			}
+			if !math.IsInf(f, 0) { //NaN and Inf are missing as in ParseGPX
+				t.trkpt.Ele = f
+			}
+		}
 	}
+	x, e := t.extra()
 	if e != nil {
-		return gpx, errf("%s: %v", gpxFileName, e)
+		return e
 	}
-	return gpx, nil
+	*p = Trkpt(t.trkpt)
+	p.Extra = x
+	return nil
 }
 
 /*
 ParseGPX parses lat, lon and ele values of _all_ track points from GPX
 file data and builds from the track points a GPX struct with a single track
 with a single track segment. Validity of the xml-format is not checked.
+The GPX data can be embedded in a larger XML or HTML document, e.g. a
+SOAP response: content before the <gpx> root is not read as header data
+and content after the last track point is skipped. GPX text escaped in
+HTML, e.g. &lt;trkpt in a <pre> block, must be unescaped first.
 A track point error is given if all three numbers are not found.
 ParseGPX is 25 x faster than encoding/xml.Unmarshal
+Existing data in gpx is replaced. If gpx has a single empty track
+segment, e.g. after Reset, it is reused with its capacity, which grows
+as needed, and the excess capacity is kept for the next parse after
+Reset, so that parsing files of similar sizes to the same gpx allocates
+no track points after the first ones. Otherwise a new segment
+with its capacity clipped to the track points is allocated, a non-empty
+segment is never overwritten. On error gpx is left without tracks,
+so TrkpSlice returns nil and IsEmpty is true. ErrCount still counts the
+ignored track point errors.
 */
 func ParseGPX(gpxbytes []byte, gpx *GPX, ignoreErrors bool) error {
-	var trkpSlice []byte
-	var points int
+	return ParseGPXWithOptions(gpxbytes, gpx, ParseOptions{IgnoreErrors: ignoreErrors})
+}
+
+// ParseGPXWithOptions is like ParseGPX, but parsing is controlled by opts.
+// A track point without <ele> element is an error, as in ParseGPX, unless
+// opts.AllowMissingElevation is set, then its Ele is NaN.
+// opts.UseXMLParser is ignored.
+func ParseGPXWithOptions(gpxbytes []byte, gpx *GPX, opts ParseOptions) error {
+	p := &parser{opts: opts}
+	return p.parse(gpxbytes, gpx)
+}
+
+// ParseGPXContext is like ParseGPX, but parsing is aborted when ctx is done.
+// ctx is checked every ctxCheckInterval track points. On cancellation
+// ctx.Err() is returned and the partially parsed track is discarded.
+func ParseGPXContext(ctx context.Context, gpxbytes []byte, gpx *GPX, ignoreErrors bool) error {
+	p := &parser{
+		opts: ParseOptions{IgnoreErrors: ignoreErrors},
+		ctx:  ctx,
+	}
+	return p.parse(gpxbytes, gpx)
+}
+
+// ParseGPXHint is like ParseGPX, but if pointHint > 0, it is used as the
+// initial track point capacity instead of the estimate from gpxbytes.
+func ParseGPXHint(gpxbytes []byte, gpx *GPX, pointHint int, ignoreErrors bool) error {
+	p := &parser{
+		opts:      ParseOptions{IgnoreErrors: ignoreErrors},
+		pointHint: pointHint,
+	}
+	return p.parse(gpxbytes, gpx)
+}
+
+/*
+ParseGPXLimit is like ParseGPX, but parsing stops after limit valid
+track points, if limit > 0. The track is then a prefix of the whole
+track, not a sample of it, and data after it is not scanned.
+*/
+func ParseGPXLimit(gpxbytes []byte, gpx *GPX, limit int, ignoreErrors bool) error {
+	p := &parser{
+		opts:  ParseOptions{IgnoreErrors: ignoreErrors},
+		limit: limit,
+	}
+	return p.parse(gpxbytes, gpx)
+}
+
+/*
+ParseGPXStats is like ParseGPXWithOptions, but it also returns parser
+metrics. Many Retries mean that the lengths of track points vary so much,
+that the closing tag search heuristic of the parser is often defeated.
+*/
+func ParseGPXStats(gpxbytes []byte, gpx *GPX, opts ParseOptions) (ParseStats, error) {
+	p := &parser{opts: opts}
+	errcnt := gpx.errcnt
+	e := p.parse(gpxbytes, gpx)
+	st := ParseStats{
+		Trkpts:  len(gpx.firstTrkpts()),
+		Errors:  gpx.errcnt - errcnt,
+		Retries: p.retries,
+	}
+	if p.rest != nil {
+		st.Bytes = len(gpxbytes) - len(p.rest)
+	}
+	return st, e
+}
 
-	gpxbytes, e := selectTrkSegment(gpxbytes)
+/*
+ParseGPXProgress is like ParseGPX, but progress is called every
+ctxCheckInterval (4096) track points with the count of valid track points
+so far. EstimateTrackpoints gives the expected total for a percentage.
+*/
+func ParseGPXProgress(gpxbytes []byte, gpx *GPX, ignoreErrors bool, progress func(points int)) error {
+	p := &parser{
+		opts:     ParseOptions{IgnoreErrors: ignoreErrors},
+		progress: progress,
+	}
+	return p.parse(gpxbytes, gpx)
+}
+
+// EstimateTrackpoints returns the estimated number of track points in GPX
+// data, which ParseGPX uses as the initial capacity of the track points.
+func EstimateTrackpoints(gpxbytes []byte) int {
+	p := &parser{}
+	p.setTags()
+	b, e := p.selectTrkSegment(trimBOM(gpxbytes))
+	if e != nil {
+		return 0
+	}
+	return p.init(b)
+}
+
+// parse is the fast parser behind ParseGPX and its variants.
+// On any error gpx is left without tracks.
+func (p *parser) parse(gpxbytes []byte, gpx *GPX) error {
+	e := p.parseGPX(gpxbytes, gpx)
+	gpx.offsets, gpx.offsOpts = p.offsets, nil
 	if e != nil {
+		gpx.Trks, gpx.offsets = nil, nil //discard partial results
+	} else if p.offsets != nil {
+		opts := p.opts
+		gpx.offsOpts = &opts
+	}
+	return e
+}
+
+// parseGPX does the parsing of parse.
+func (p *parser) parseGPX(gpxbytes []byte, gpx *GPX) error {
+	if e := p.setTags(); e != nil {
+		return e
+	}
+	if p.opts.tooLarge(int64(len(gpxbytes))) {
+		return ErrTooLarge
+	}
+	p.data = gpxbytes
+	data := trimBOM(gpxbytes)
+	gpxbytes, e := p.selectTrkSegment(data)
+	if e != nil {
+		return e
+	}
+	gpx.estimate = p.init(gpxbytes)
+	trkseg, reused := makeTrkseg(gpx.estimate, gpx)
+	head := gpxRoot(textUTF8(data, data[:len(data)-len(gpxbytes)]))
+	trk := &gpx.Trks[0]
+	trk.Name, trk.Cmt, trk.Desc = trackText(head)
+	gpx.parseHeader(head)
+	errcnt, e := p.parseTrkpts(gpxbytes, trkseg)
+	gpx.errcnt += errcnt
+	gpx.warncnt += p.warncnt
+	switch {
+	case e != nil && e == p.ctxErr():
 		return e
+	case e != nil:
+		return errf("trackpoint %d: %w", len(*trkseg)+1, e)
+	case len(*trkseg) == 0:
+		return ErrNoTrackpoints
 	}
-	points, trkpLen = trkpCountEstimate(gpxbytes)
-	startSearch = trkpLen - (len(closetag) + 2)
-	trkseg := makeTrkseg(points, gpx)
-	trkpnum := 0
+	if p.opts.StrictXML {
+		if e := CheckXML(data); e != nil {
+			return e
+		}
+	}
+	if !reused {
+		clipTrkseg(gpx) //clip excess capacity
+	}
+	return nil
+}
+
+// setTags sets the tags of p from p.opts.Tags.
+func (p *parser) setTags() error {
+	t := p.opts.Tags
+	p.starttag, p.closetag, p.eletag, p.timetag = starttag, closetag, eletag, timetag
+	for _, tag := range []string{t.Trkpt, t.Ele, t.Time} {
+		if tag != "" && tag[0] != '<' {
+			return errf("%w: %q", ErrInvalidTag, tag)
+		}
+	}
+	if t.Trkpt != "" {
+		p.starttag = []byte(t.Trkpt)
+		p.closetag = []byte("</" + t.Trkpt[1:] + ">")
+	}
+	if t.Ele != "" {
+		p.eletag = []byte(t.Ele)
+	}
+	if t.Time != "" {
+		p.timetag = []byte(t.Time)
+	}
+	return nil
+}
+
+// init sets the track point length estimate of p for gpxbytes and
+// returns the estimated number of track points, or p.pointHint if set.
+func (p *parser) init(gpxbytes []byte) (points int) {
+	points, p.trkpLen = trkpCountEstimate(gpxbytes, p.starttag)
+	p.startSearch = max(p.trkpLen-(len(p.closetag)+2), 0)
+	if p.pointHint > 0 {
+		points = p.pointHint
+	}
+	if p.limit > 0 {
+		points = min(points, p.limit)
+	}
+	return points
+}
+
+// parseTrkpts appends all valid track points of gpxbytes, at most p.limit
+// if it is set, to trkseg.
+// It returns the count of ignored track point errors, or the first
+// error if errors are not ignored or ctx is done.
+func (p *parser) parseTrkpts(gpxbytes []byte, trkseg *[]Trkpt) (errcnt int, err error) {
+	for n := 1; ; n++ {
+		if n%ctxCheckInterval == 0 {
+			if e := p.ctxErr(); e != nil {
+				return errcnt, e
+			}
+			if p.progress != nil {
+				p.progress(len(*trkseg))
+			}
+		}
+		if p.limit > 0 && len(*trkseg) >= p.limit {
+			return errcnt, nil
+		}
+		trkp, trkpSlice, e := p.next(&gpxbytes, &errcnt)
+		if e != nil || trkpSlice == nil {
+			return errcnt, e
+		}
+		*trkseg = append(*trkseg, trkp)
+		if p.opts.TrackOffsets {
+			p.offsets = append(p.offsets, p.offset(trkpSlice))
+		}
+	}
+}
+
+/*
+next parses the next valid track point of *b and advances *b past it.
+It returns the track point and its raw slice, or a nil slice at the end
+of the track points. Ignored errors are counted to errcnt. Otherwise an
+error with a non-nil slice is an error of a single track point, after
+which the next call continues with the following one.
+*/
+func (p *parser) next(b *[]byte, errcnt *int) (trkp Trkpt, trkpSlice []byte, err error) {
 	for {
-		trkpSlice, gpxbytes = nextTrkpt(gpxbytes)
+		prev := *b
+		trkpSlice, *b = p.nextTrkpt(*b)
 		if trkpSlice == nil {
-			break
+			if next := p.skipTrkpt(*b); next != nil {
+				*errcnt++
+				*b = next
+				continue
+			}
+			return Trkpt{}, nil, p.unclosed(*b, errcnt)
 		}
-		trkp, err := parseTrkpt(trkpSlice)
+		if k := bytes.Index(trkpSlice, p.starttag); k >= 0 { //no </trkpt> before next <trkpt, indexTag may skip it
+			*b = prev[cap(prev)-cap(trkpSlice)+k:] //also after a self-closing <trkpt/>
+			if !p.opts.IgnoreErrors {
+				return Trkpt{}, trkpSlice, errf("%s: %w", p.position(trkpSlice), ErrUnbalanced)
+			}
+			*errcnt++
+			continue
+		}
+		p.rest = *b
+		trkp, err = p.parseTrkpt(trkpSlice)
 		switch {
 		case err == nil:
-			trkpnum++
-			*trkseg = append(*trkseg, trkp)
-		case ignoreErrors:
-			gpx.errcnt++
+			return trkp, trkpSlice, nil
+		case p.opts.IgnoreErrors:
+			*errcnt++
 		default:
-			return errf("trackpoint %d: %v", trkpnum+1, err)
+			return Trkpt{}, trkpSlice, errf("%s: %w: %s", p.position(trkpSlice), err, trkpSlice)
 		}
 	}
-	if trkpnum == 0 {
-		return errf("No valid trackpoints found")
+}
+
+// skipTrkpt returns tail from the second <trkpt on, if errors are ignored,
+// so that parsing continues after a corrupted track point, which
+// nextTrkpt fails to extract. Otherwise, or if there is no second
+// <trkpt, it returns nil.
+func (p *parser) skipTrkpt(tail []byte) []byte {
+	if !p.opts.IgnoreErrors {
+		return nil
 	}
-	clipTrkseg(gpx) //clip excess capacity
-	return nil
+	k := indexTag(tail, p.starttag)
+	if k < 0 {
+		return nil
+	}
+	k += len(p.starttag)
+	n := indexTag(tail[k:], p.starttag)
+	if n < 0 {
+		return nil
+	}
+	return tail[k+n:]
 }
 
-// selectTrkSegment is not implemented yet.
-func selectTrkSegment(b []byte) ([]byte, error) {
-	d := indexTag(b, starttag)
+// unclosed checks the data tail after the last track point for a <trkpt
+// without a closing tag, e.g. in a truncated file. It is an ErrUnbalanced
+// error, or it is counted in errcnt, if errors are ignored.
+func (p *parser) unclosed(tail []byte, errcnt *int) error {
+	k := indexTag(tail, p.starttag)
+	if k < 0 {
+		return nil
+	}
+	if p.opts.IgnoreErrors {
+		*errcnt++
+		return nil
+	}
+	return errf("%s: %w", p.position(tail[min(k+len(p.starttag)+1, len(tail)):]), ErrUnbalanced)
+}
+
+/*
+position returns the line number and byte offset of the <trkpt tag
+of track point slice trkpSlice in p.data. trkpSlice shares the
+underlying array of p.data, so the offset is the difference of
+their capacities.
+*/
+func (p *parser) position(trkpSlice []byte) string {
+	off := p.offset(trkpSlice)
+	if off < 0 || off > len(p.data) {
+		return "unknown position"
+	}
+	line := bytes.Count(p.data[:off], []byte{'\n'}) + 1
+	return fmt.Sprintf("line %d offset %d", line, off)
+}
+
+// offset returns the byte offset of the <trkpt tag of trkpSlice in p.data.
+func (p *parser) offset(trkpSlice []byte) int {
+	return cap(p.data) - cap(trkpSlice) - (len(p.starttag) + 1)
+}
+
+// ctxErr returns the error of p.ctx, nil if p.ctx is nil or not done.
+func (p *parser) ctxErr() error {
+	if p.ctx == nil {
+		return nil
+	}
+	return p.ctx.Err()
+}
+
+// trimBOM drops a leading UTF-8 byte order mark and white space
+// before the XML declaration from b.
+func trimBOM(b []byte) []byte {
+	b = bytes.TrimPrefix(b, bom)
+	return bytes.TrimLeft(b, " \t\r\n")
+}
+
+// selectTrkSegment returns b from the first track point on. If there is
+// no <trkpt and no custom Trkpt tag, namespace prefixed tags are checked.
+func (p *parser) selectTrkSegment(b []byte) ([]byte, error) {
+	d := indexTag(b, p.starttag)
+	if d < 0 && p.opts.Tags.Trkpt == "" {
+		d = p.prefixTags(b)
+	}
 	if d < 0 {
-		return b, errf("No track points found")
+		return b, ErrNoTrackpoints
 	}
 	return b[d:], nil //drop everything before first track point
 }
 
+/*
+prefixTags sets the trkpt tags of p to namespace prefixed tags, e.g.
+<ns2:trkpt and </ns2:trkpt>, by the first prefixed track point in b and
+returns its index, or -1 if there is none. The ele and time tags get the
+prefix, if not custom and b has prefixed elements, e.g. <ns2:ele>.
+*/
+func (p *parser) prefixTags(b []byte) int {
+	local := []byte(":" + string(starttag[1:]))
+	for j := 0; ; {
+		d := bytes.Index(b[j:], local)
+		if d < 0 {
+			return -1
+		}
+		j += d
+		k := j + len(local)
+		l := bytes.LastIndexAny(b[:j], "< \t\r\n>/")
+		if l < 0 || b[l] != '<' || l+1 == j || k == len(b) || (b[k] > ' ' && b[k] != '>' && b[k] != '/') {
+			j = k
+			continue
+		}
+		prefix := string(b[l+1 : j+1]) //e.g. "ns2:"
+		p.starttag = []byte("<" + prefix + "trkpt")
+		p.closetag = []byte("</" + prefix + "trkpt>")
+		if tag := []byte("<" + prefix + "ele>"); p.opts.Tags.Ele == "" && bytes.Contains(b, tag) {
+			p.eletag = tag
+		}
+		if tag := []byte("<" + prefix + "time>"); p.opts.Tags.Time == "" && bytes.Contains(b, tag) {
+			p.timetag = tag
+		}
+		return l
+	}
+}
+
 /*
 nextTrkpt returns the first trackpoint slice of the slice gpxbytes.
 nextTrkpt also returnsa a modified gpxbytes, which is the tail of gpxbytes,
@@ -129,119 +752,279 @@ The trackpoint of the returned slice is removed from gpxbytes.
 Track point slice can have any other data, unless it is not
 disturbing parsing of lat, lon and ele values. So
 <trkpt lon "  -5.760211" lat    "37.942557" <ele>615.25<
+A self-closing track point <trkpt lon="-5.760211" lat="37.942557"/>
+has no closing tag and is returned as
+lon="-5.760211" lat="37.942557"
 */
-func nextTrkpt(gpxbytes []byte) (trkpSlice, gpxbytesTail []byte) {
-	const startTagLen = 6
-	const closeTagLen = 8
+func (p *parser) nextTrkpt(gpxbytes []byte) (trkpSlice, gpxbytesTail []byte) {
+	startTagLen := len(p.starttag)
+	closeTagLen := len(p.closetag)
 
 	b := gpxbytes
-	if len(b) < startSearch {
-		return nil, b
-	}
-	l := indexTag(b, starttag)
+	l := indexTag(b, p.starttag)
 	if l < 0 {
 		return nil, b
 	}
 	l += startTagLen + 1 //skip opening tag
-	r := startSearch     //skip most data
-	d := indexTag(b[r:], closetag)
-	if d < 0 {
+	g := indexByte(b[l:], '>') + l
+	if g < l {
 		return nil, b
 	}
-	if d > closeTagLen+20 { //missed (or missing) closing tag, retry
-		startSearch-- //next time start search from one byte earlier
-		r = l + 20
-		d = indexTag(b[r:], closetag)
+	if b[g-1] == '/' { //self-closing <trkpt ... />
+		return b[l : g-1], b[g+1:]
+	}
+	r := min(p.startSearch, len(b)) //skip most data
+	d := indexTag(b[r:], p.closetag)
+	//missed (or missing) closing tag or skipped over the next <trkpt, retry
+	if d < 0 || d > closeTagLen+20 || r > g && bytes.Index(b[g:r], p.starttag) >= 0 {
+		p.startSearch = max(p.startSearch-1, 0) //next time start search from one byte earlier
+		p.retries++
+		r = g + 1 //the closing tag is after the start tag
+		d = indexTag(b[r:], p.closetag)
+		if d < 0 {
+			return nil, b
+		}
 	}
 	r += d
 	return b[l:r], b[r+closeTagLen:] //drop the first trkpt with closing tag
 }
 
 /*
-parseTrkpt parses lat, lon and ele values from track point slice b
-and returns a track point with these values. Track point slice is
-supposed to be like below, attributes lat and lon before elevation.
+parseTrkpt parses lat, lon, ele and optional time and TrkptExtra values
+from track point slice b and returns a track point with these values.
+Track point slice is supposed to be like below, attributes lat and lon
+before elevation.
 
-	lon="-5.760211" lat="37.942557"> <ele>615.25</ele>
+	lon="-5.760211" lat="37.942557"> <ele>615.25</ele> <time>2023-06-19T11:27:32Z</time>
 
 White space around numbers is trimmed off and ignored elsewhere.
 '+' before number is accepted. Error is given for missing data or
-not properly formatted numbers. Errors may come from numconv.Atof,
-which is used for parsing numbers.
+not properly formatted numbers, see the Err variables. Missing
+elevation is an error unless p.opts.AllowMissingElevation is set,
+then Ele is NaN. An empty, NaN or Inf <ele> is missing, but
+NaN and Inf coordinates are errors.
 */
-func parseTrkpt(b []byte) (Trkpt, error) {
-	var e1, e2, e3 error
+func (p *parser) parseTrkpt(b []byte) (Trkpt, error) {
+	var e1, e2, e3, e4, e5 error
 	var point Trkpt
 
-	point.Lon, e1 = parseCoordinate(b, lonname)
-	point.Lat, e2 = parseCoordinate(b, latname)
-	point.Ele, e3 = parseElevation(b, eletag)
+	if p.opts.DecimalComma {
+		b = p.commasToDots(b)
+	}
+	point.Lon, e1 = parseCoordinate(b, lonname, ErrMissingLongitude)
+	point.Lat, e2 = parseCoordinate(b, latname, ErrMissingLatitude)
+	point.Ele, e3 = parseElevation(b, p.eletag, !p.opts.AllowMissingElevation)
+	if u := p.opts.ElevationUnit; u != 0 {
+		point.Ele *= float64(u)
+	}
+	point.Time, e4 = parseTimeTag(b, p.timetag)
+	point.Extra, e5 = parseExtra(b)
+	partial := p.opts.PartialCoordinates && (e1 == nil) != (e2 == nil)
+	if partial {
+		if e1 != nil {
+			point.Lon = math.NaN()
+		} else {
+			point.Lat = math.NaN()
+		}
+		e1, e2 = nil, nil
+	}
 	if e1 == nil {
 		e1 = e2
 	}
 	if e1 == nil {
 		e1 = e3
 	}
+	if e1 == nil {
+		e1 = e4
+	}
+	if e1 == nil {
+		e1 = e5
+	}
+	if partial && e1 == nil {
+		p.warncnt++
+	}
 	return point, e1
 }
 
-// parseElevatione returns elevation value from the trackpoint slice b.
-func parseElevation(b, eletag []byte) (float64, error) {
-	const eleKeyLen = 5
-	const attribLen = 20
+// commasToDots returns b with decimal commas replaced by dots. If b has
+// commas, the result is a copy in p.buf, which is reused.
+func (p *parser) commasToDots(b []byte) []byte {
+	if indexByte(b, ',') < 0 {
+		return b
+	}
+	p.buf = append(p.buf[:0], b...)
+	for i, c := range p.buf {
+		if c == ',' {
+			p.buf[i] = '.'
+		}
+	}
+	return p.buf
+}
 
-	l := attribLen //skip some lat and lon data
-	d := indexTag(b[l:], eletag)
-	if d < 0 {
-		return 0, errf("missing elevation tag: %s", b)
+/*
+parseElevation returns elevation value from the trackpoint slice b.
+If the elevation tag is missing and not required, NaN is returned.
+The whole slice is searched, so <ele> can be anywhere in it, also
+before lat and lon or after other elements.
+*/
+func parseElevation(b, eletag []byte, required bool) (float64, error) {
+	l := indexElemTag(b, eletag) //from start, short lat and lon may end before any offset
+	if l < 0 {
+		if !required {
+			return math.NaN(), nil
+		}
+		return 0, ErrMissingElevation
 	}
-	l += d + eleKeyLen
+	l += len(eletag)
 	r := indexByte(b[l:], '<') + l //only this, not full </ele>
 	if r < l {
-		return 0, errf("invalid elevation syntax: %s", b)
+		return 0, ErrSyntax
 	}
-	if use_std_library {
-		return strconv.ParseFloat(string(bytes.TrimSpace(b[l:r])), 64)
+	if len(trimSpace(b[l:r])) == 0 { //empty <ele></ele>
+		return parseElevation(nil, eletag, required)
 	}
-	return numconv.Atof(numconv.Trim(b[l:r]))
+	f, e := parseFloat(b[l:r])
+	if e != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		if isNonFinite(b[l:r]) { //NaN or Inf is missing elevation
+			return parseElevation(nil, eletag, required)
+		}
+		return 0, ErrInvalidElevation
+	}
+	return f, nil
+}
+
+// isNonFinite reports whether number slice b is NaN or an infinity, e.g.
+// written by a buggy exporter. They are not valid GPX decimals.
+func isNonFinite(b []byte) bool {
+	f, e := strconv.ParseFloat(string(trimSpace(b)), 64)
+	return e == nil && (math.IsNaN(f) || math.IsInf(f, 0))
 }
 
-// parseCoordinate returns the float64 value of latitude or longitude
-// koordinate from the trackpoint slice b.
-func parseCoordinate(b []byte, name []byte) (float64, error) {
-	const nameLen = 3 + 1
-	const skipDigits = 4
+// parseTimeTag returns the time value from the trackpoint slice b.
+// If the time tag is missing, zero time is returned.
+func parseTimeTag(b, timetag []byte) (time.Time, error) {
+	l := indexElemTag(b, timetag)
+	if l < 0 {
+		return time.Time{}, nil
+	}
+	l += len(timetag)
+	r := indexByte(b[l:], '<') + l
+	if r < l {
+		return time.Time{}, ErrSyntax
+	}
+	t, e := parseTime(b[l:r])
+	if e != nil {
+		return time.Time{}, ErrInvalidTime
+	}
+	return t, nil
+}
 
-	l := bytes.Index(b, name) + nameLen
-	if l < nameLen {
-		return 0, errf("missing "+string(name)+" attribute: %s", b)
+/*
+parseCoordinate returns the float64 value of latitude or longitude
+koordinate from the trackpoint slice b. errMissing is returned if
+attribute name is not found. White space is allowed around = and
+inside the quotes, e.g. lat = " 37.94 ".
+*/
+func parseCoordinate(b []byte, name []byte, errMissing error) (float64, error) {
+	l := indexAttr(b, name)
+	if l < 0 {
+		return 0, errMissing
 	}
-	l += indexByte(b[l:], quotemark) + 1
-	k := l + skipDigits
-	r := indexByte(b[k:], quotemark) + k
-	if r < k {
-		return 0, errf("missing "+string(name)+" quotemark: %s", b)
+	q := indexQuote(b[l:]) //either " or '
+	if q < 0 {
+		return 0, ErrSyntax
 	}
-	if use_std_library {
-		return strconv.ParseFloat(string(bytes.TrimSpace(b[l:r])), 64)
+	l += q
+	quotemark := b[l]
+	l++
+	r := indexByte(b[l:], quotemark) + l
+	if r < l {
+		return 0, ErrSyntax
+	}
+	f, e := parseFloat(b[l:r])
+	if e != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0, ErrInvalidCoordinate
+	}
+	return f, nil
+}
+
+// indexAttr returns the index after attribute name and the following
+// = in b, or -1 if not found. A missing = is tolerated before a quote.
+// name must be a whole word, so that e.g. lat is not found in a value
+// or in an attribute like plate="1".
+func indexAttr(b, name []byte) int {
+	j := 0
+	for {
+		d := bytes.Index(b[j:], name)
+		if d < 0 {
+			return -1
+		}
+		j += d
+		k := j + len(name)
+		for k < len(b) && b[k] <= ' ' {
+			k++
+		}
+		if k < len(b) && (j == 0 || b[j-1] <= ' ') {
+			switch b[k] {
+			case '=':
+				return k + 1
+			case '"', '\'':
+				return k
+			}
+		}
+		j += len(name)
 	}
-	return numconv.Atof(numconv.Trim(b[l:r]))
 }
 
 // Only the first track segment in GPX is used. Even if XML parser
 // is used and there are several tracks and segments. ParseGPX puts
 // all track points to the first track segment.
+// TrkpSlice returns nil if there is no track segment, e.g. after any
+// ParseGPX error. The slice aliases the track points of gpx.
 func (gpx *GPX) TrkpSlice() []Trkpt {
-	return gpx.Trks[0].Trksegs[0].Trkpts
+	return gpx.firstTrkpts()
 }
 
+// TrkpSliceCopy is like TrkpSlice, but it returns a copy of the track
+// points with copies of their Extra. It returns an empty non-nil slice,
+// if there are no track points.
 func (gpx *GPX) TrkpSliceCopy() []Trkpt {
-	s := gpx.Trks[0].Trksegs[0].Trkpts
-	return append([]Trkpt{}, s...)
+	if c := copyTrkpts(gpx.firstTrkpts()); c != nil {
+		return c
+	}
+	return []Trkpt{}
+}
+
+// Clone returns a deep copy of gpx. The copy shares no slices with gpx.
+func (gpx *GPX) Clone() *GPX {
+	c := *gpx
+	c.Trks = make([]Trk, len(gpx.Trks))
+	for i, trk := range gpx.Trks {
+		c.Trks[i] = trk
+		c.Trks[i].Trksegs = make([]Trkseg, len(trk.Trksegs))
+		for j, seg := range trk.Trksegs {
+			c.Trks[i].Trksegs[j].Trkpts = copyTrkpts(seg.Trkpts)
+		}
+	}
+	return &c
+}
+
+// copyTrkpts returns a copy of track points s with copies of their Extra.
+func copyTrkpts(s []Trkpt) []Trkpt {
+	c := append([]Trkpt(nil), s...)
+	for k := range c {
+		if c[k].Extra != nil {
+			x := *c[k].Extra
+			c[k].Extra = &x
+		}
+	}
+	return c
 }
 
 func (gpx *GPX) TrkpSliceRelease() {
-	gpx.Trks[0].Trksegs[0].Trkpts = nil
+	if len(gpx.Trks) > 0 && len(gpx.Trks[0].Trksegs) > 0 {
+		gpx.Trks[0].Trksegs[0].Trkpts = nil
+	}
 }
 
 // clipTrkseg clips excess capacity from the single gpx track segment []Trkpt.
@@ -250,12 +1033,104 @@ func clipTrkseg(gpx *GPX) {
 	gpx.Trks[0].Trksegs[0].Trkpts = s[:len(s):len(s)]
 }
 
+/*
+Compact reallocates each track segment with excess capacity, e.g. after
+in-place filters like Simplify or ClipToBounds, to a new array of its
+length, so that the old array can be freed. In-place filters do not do
+it themselves, as the copy costs as much as a pass over the points, and
+it is seldom worth it for a short-lived GPX. It is for keeping many
+filtered tracks in memory. Slices from gpx, e.g. TrkpSlice, keep the old
+array alive.
+*/
+func (gpx *GPX) Compact() {
+	for _, trk := range gpx.Trks {
+		for j, seg := range trk.Trksegs {
+			s := seg.Trkpts
+			if cap(s) == len(s) {
+				continue
+			}
+			var c []Trkpt
+			if len(s) > 0 {
+				c = make([]Trkpt, len(s))
+				copy(c, s)
+			}
+			trk.Trksegs[j].Trkpts = c
+		}
+	}
+}
+
+// TotalPoints returns the number of track points in all track segments.
+func (gpx *GPX) TotalPoints() int {
+	n := 0
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			n += len(seg.Trkpts)
+		}
+	}
+	return n
+}
+
+// IsEmpty reports whether gpx has no track points.
+func (gpx *GPX) IsEmpty() bool {
+	return gpx.TotalPoints() == 0
+}
+
+// CapacityEstimate returns the initial track point capacity estimated
+// by the last ParseGPX of gpx and the actual number of track points, e.g.
+// for tuning ParseGPXHint. The estimate is 0 for other parsers.
+func (gpx *GPX) CapacityEstimate() (estimated, actual int) {
+	return gpx.estimate, len(gpx.firstTrkpts())
+}
+
+// Track returns track index of gpx, false if there is no such track.
+func (gpx *GPX) Track(index int) (*Trk, bool) {
+	if index < 0 || index >= len(gpx.Trks) {
+		return nil, false
+	}
+	return &gpx.Trks[index], true
+}
+
+// TrackByName returns the first track of gpx named name, false if
+// there is no such track.
+func (gpx *GPX) TrackByName(name string) (*Trk, bool) {
+	for i := range gpx.Trks {
+		if gpx.Trks[i].Name == name {
+			return &gpx.Trks[i], true
+		}
+	}
+	return nil, false
+}
+
+// HasEle reports whether the track point has elevation data.
+func (p Trkpt) HasEle() bool {
+	return !math.IsNaN(p.Ele)
+}
+
 func (gpx *GPX) ErrCount() int {
 	return gpx.errcnt
 }
 
-// trkpCountEstimate estimates the number of track points in GPX data.
-func trkpCountEstimate(data []byte) (count, lenght int) {
+/*
+Offsets returns the byte offsets of the <trkpt tags of the track points
+of the first track segment in the parsed data, if parsed with the
+TrackOffsets option, otherwise nil. Offsets[i] is the offset of
+TrkpSlice()[i] as parsed, modifying the track points does not update
+the offsets. The slice is shared by gpx and its clones and it must not
+be modified.
+*/
+func (gpx *GPX) Offsets() []int {
+	return gpx.offsets
+}
+
+// WarnCount returns the count of track points kept with a NaN lat or lon
+// by ParseOptions.PartialCoordinates.
+func (gpx *GPX) WarnCount() int {
+	return gpx.warncnt
+}
+
+// trkpCountEstimate estimates the number of track points in GPX data
+// with track point start tag starttag.
+func trkpCountEstimate(data, starttag []byte) (count, lenght int) {
 	const minLen = 24
 	if len(data) < 500 {
 		return 1, minLen
@@ -272,20 +1147,74 @@ func trkpCountEstimate(data []byte) (count, lenght int) {
 	return int(float64(len(data)/trkpLen) * 1.0), trkpLen
 }
 
-// makeTrkseg initializes *GPX and allocates a track segment of capacity points
-// to it, Returns a pointer to track segment.
-func makeTrkseg(points int, gpx *GPX) *[]Trkpt {
-	gpx.Trks = append(gpx.Trks, Trk{})
-	gpx.Trks[0].Trksegs = append(gpx.Trks[0].Trksegs, Trkseg{})
-	trkseg := &gpx.Trks[0].Trksegs[0].Trkpts
-	*trkseg = make([]Trkpt, 0, points)
-	return trkseg
+// makeTrkseg initializes *GPX to a single track with a single track segment
+// of capacity points. Returns a pointer to track segment and whether the
+// empty single track segment of gpx (see Reset) was reused. It keeps its
+// capacity, which append grows, if it is less than the actual number of
+// track points, and its capacity should not be clipped.
+func makeTrkseg(points int, gpx *GPX) (trkseg *[]Trkpt, reused bool) {
+	s := gpx.firstTrkpts()
+	if len(s) > 0 || cap(s) == 0 || len(gpx.Trks) != 1 || len(gpx.Trks[0].Trksegs) != 1 {
+		gpx.Trks = []Trk{{Trksegs: []Trkseg{{Trkpts: make([]Trkpt, 0, points)}}}}
+		return &gpx.Trks[0].Trksegs[0].Trkpts, false
+	}
+	gpx.Trks[0] = Trk{Trksegs: gpx.Trks[0].Trksegs}
+	return &gpx.Trks[0].Trksegs[0].Trkpts, true
+}
+
+// firstTrkpts returns the track points of the first track segment,
+// nil if there is none.
+func (gpx *GPX) firstTrkpts() []Trkpt {
+	if len(gpx.Trks) == 0 || len(gpx.Trks[0].Trksegs) == 0 {
+		return nil
+	}
+	return gpx.Trks[0].Trksegs[0].Trkpts
+}
+
+// parseFloat returns the float64 value of number slice b. White space,
+// including tabs, CR and LF, around the number is trimmed off. Numbers in
+// scientific notation, e.g. 6.1525e2, which numconv.Atof may not accept,
+// are parsed by strconv.ParseFloat.
+func parseFloat(b []byte) (float64, error) {
+	if use_std_library {
+		return strconv.ParseFloat(string(bytes.TrimSpace(b)), 64)
+	}
+	b = trimSpace(b)
+	f, e := numconv.Atof(b)
+	if e != nil && bytes.IndexAny(b, "eE") >= 0 {
+		return strconv.ParseFloat(string(b), 64)
+	}
+	return f, e
+}
+
+// trimSpace returns b without leading and trailing bytes <= ' ',
+// which are space, tab, CR, LF and other control characters.
+func trimSpace(b []byte) []byte {
+	l, r := 0, len(b)
+	for l < r && b[l] <= ' ' {
+		l++
+	}
+	for r > l && b[r-1] <= ' ' {
+		r--
+	}
+	return b[l:r]
+}
+
+// indexQuote returns the index of the first quotemark " or ' in b,
+// or -1 if neither is present in b.
+func indexQuote(b []byte) int {
+	for i, x := range b {
+		if x == '"' || x == '\'' {
+			return i
+		}
+	}
+	return -1
 }
 
 // indexByte returns the index of the first instance of c in b,
 // or -1 if c is not present in b.
 func indexByte(b []byte, c byte) int {
-	if use_std_library {
+	if stdSearch {
 		return bytes.IndexByte(b, c)
 	}
 	for i, x := range b {
@@ -296,12 +1225,21 @@ func indexByte(b []byte, c byte) int {
 	return -1
 }
 
+// indexElemTag is indexTag, which does not miss tag after a short
+// element, e.g. <ele> after </x>. Only a miss of indexTag is rechecked.
+func indexElemTag(b, tag []byte) int {
+	if l := indexTag(b, tag); l >= 0 {
+		return l
+	}
+	return bytes.Index(b, tag)
+}
+
 // indexTag returns starting index of XML tag tag []byte.
 // Otherwise it is like bytes.Index, but faster for short
 // distances and short XML tags: e.g. <trkpt, <ele> and </trkpt>.
 // indexTag is inlineable function. Just
 func indexTag(b, tag []byte) int {
-	if use_std_library {
+	if stdSearch {
 		return bytes.Index(b, tag)
 	}
 	j := 0
@@ -0,0 +1,536 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// readTestdata returns the content of file name in testdata.
+func readTestdata(t testing.TB, name string) []byte {
+	t.Helper()
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// lenient are ParseOptions, with which elevation is optional.
+var lenient = ParseOptions{AllowMissingElevation: true}
+
+// parseTestdata returns file name in testdata parsed by Parse with
+// lenient options.
+func parseTestdata(t testing.TB, name string) *GPX {
+	t.Helper()
+	gpx, err := Parse(readTestdata(t, name), lenient)
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+	return gpx
+}
+
+// genTrack returns a GPX document of n track points, each followed by
+// extension data of extLen bytes, and then of m track points without
+// extensions.
+func genTrack(n, extLen, m int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0"?><gpx version="1.1" creator="gpx test"><trk><trkseg>`)
+	ext := bytes.Repeat([]byte("x"), extLen)
+	for i := range n + m {
+		fmt.Fprintf(&b, `<trkpt lat="%.5f" lon="24.90000"><ele>%d</ele>`, 60+float64(i)/1e4, i%100)
+		if i < n {
+			fmt.Fprintf(&b, "<extensions><note>%s</note></extensions>", ext)
+		}
+		b.WriteString("</trkpt>\n")
+	}
+	b.WriteString("</trkseg></trk></gpx>\n")
+	return b.Bytes()
+}
+
+func TestParseUnbalanced(t *testing.T) {
+	tests := []struct {
+		file         string
+		ignoreErrors bool
+		points       int
+		errcnt       int
+		err          error
+	}{
+		{"truncated_trkpt.gpx", false, 0, 0, ErrUnbalanced},
+		{"truncated_trkpt.gpx", true, 4, 1, nil},
+		{"overlong_trkpt.gpx", false, 3, 0, nil},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.file, "/", tt.ignoreErrors), func(t *testing.T) {
+			var gpx GPX
+			err := ParseGPX(readTestdata(t, tt.file), &gpx, tt.ignoreErrors)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if n := len(gpx.TrkpSlice()); n != tt.points {
+				t.Errorf("points = %d, want %d", n, tt.points)
+			}
+			if n := gpx.ErrCount(); n != tt.errcnt {
+				t.Errorf("ErrCount = %d, want %d", n, tt.errcnt)
+			}
+		})
+	}
+}
+
+// Long track points before the short ones, from which the closing tag
+// search offset is estimated, retry the search many times.
+func TestParseLongThenShortPoints(t *testing.T) {
+	tests := []struct{ long, extLen, short int }{
+		{300, 200, 3000},
+		{1000, 50, 100},
+		{10, 2000, 10},
+	}
+	for _, tt := range tests {
+		var gpx GPX
+		if err := ParseGPX(genTrack(tt.long, tt.extLen, tt.short), &gpx, false); err != nil {
+			t.Fatalf("%v: %v", tt, err)
+		}
+		if n := len(gpx.TrkpSlice()); n != tt.long+tt.short {
+			t.Errorf("%v: points = %d, want %d", tt, n, tt.long+tt.short)
+		}
+	}
+}
+
+// fixture is a parse test of a testdata file.
+type fixture struct {
+	file   string
+	opts   ParseOptions
+	err    error         // expected error, nil on success
+	points int           // number of track points
+	errcnt int           // number of ignored track point errors
+	want   map[int]Trkpt // expected track points by index
+	xml    bool          // header and track text equal to the encoding/xml result, trimmed
+	xmlPts bool          // track points equal to the encoding/xml result
+	check  func(t *testing.T, gpx *GPX)
+}
+
+// nan is a missing value in test track points.
+var nan = math.NaN()
+
+// pt returns a track point with time ts in RFC 3339 format, zero if ts
+// is empty.
+func pt(lat, lon, ele float64, ts string) Trkpt {
+	p := Trkpt{Lat: lat, Lon: lon, Ele: ele}
+	if ts != "" {
+		p.Time, _ = time.Parse(time.RFC3339Nano, ts)
+	}
+	return p
+}
+
+// withExtra returns p with Extra values of the TrkptExtra elements in x.
+func withExtra(p Trkpt, x map[string]float64) Trkpt {
+	e := newTrkptExtra()
+	for name, v := range x {
+		*e.field(name) = v
+	}
+	p.Extra = &e
+	return p
+}
+
+// sameFloat reports whether a and b are equal or both NaN.
+func sameFloat(a, b float64) bool {
+	return a == b || math.IsNaN(a) && math.IsNaN(b)
+}
+
+// sameTrkpt reports whether p and q have the same values, Extra included.
+func sameTrkpt(p, q Trkpt) bool {
+	if p.Lat != q.Lat || p.Lon != q.Lon || !sameFloat(p.Ele, q.Ele) || !p.Time.Equal(q.Time) {
+		return false
+	}
+	if p.Extra == nil || q.Extra == nil {
+		return p.Extra == q.Extra
+	}
+	for _, name := range extraNames {
+		if !sameFloat(*p.Extra.field(name), *q.Extra.field(name)) {
+			return false
+		}
+	}
+	return true
+}
+
+func runFixtures(t *testing.T, tests []fixture) {
+	t.Helper()
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			data := readTestdata(t, tt.file)
+			var gpx GPX
+			err := ParseGPXWithOptions(data, &gpx, tt.opts)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			s := gpx.TrkpSlice()
+			if len(s) != tt.points {
+				t.Errorf("points = %d, want %d", len(s), tt.points)
+			}
+			if n := gpx.ErrCount(); n != tt.errcnt {
+				t.Errorf("ErrCount = %d, want %d", n, tt.errcnt)
+			}
+			for i, want := range tt.want {
+				if i >= len(s) {
+					t.Errorf("point %d missing", i)
+				} else if !sameTrkpt(s[i], want) {
+					t.Errorf("point %d = %v %v, want %v %v", i, s[i], s[i].Extra, want, want.Extra)
+				}
+			}
+			if tt.xml {
+				x, err := Parse(data, ParseOptions{UseXMLParser: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if x.Creator != gpx.Creator || x.Version != gpx.Version || x.Time != gpx.Time ||
+					x.Metadata != gpx.Metadata || len(x.Trks) == 0 {
+					t.Errorf("header %q %q %q %v, encoding/xml %q %q %q %v", gpx.Creator, gpx.Version,
+						gpx.Time, gpx.Metadata, x.Creator, x.Version, x.Time, x.Metadata)
+				} else if trk, xt := gpx.Trks[0], x.Trks[0]; trk.Name != strings.TrimSpace(xt.Name) ||
+					trk.Cmt != strings.TrimSpace(xt.Cmt) || trk.Desc != strings.TrimSpace(xt.Desc) {
+					t.Errorf("track %q %q %q, encoding/xml %q %q %q", trk.Name, trk.Cmt, trk.Desc,
+						xt.Name, xt.Cmt, xt.Desc)
+				}
+			}
+			if tt.xmlPts {
+				x, err := Parse(data, ParseOptions{UseXMLParser: true})
+				if err != nil {
+					t.Fatal(err)
+				}
+				xs := x.TrkpSlice()
+				for i := range min(len(s), len(xs)) {
+					if !sameTrkpt(s[i], xs[i]) {
+						t.Errorf("point %d = %v %v, encoding/xml %v %v", i, s[i], s[i].Extra, xs[i], xs[i].Extra)
+					}
+				}
+				if len(xs) != len(s) {
+					t.Errorf("points = %d, encoding/xml %d", len(s), len(xs))
+				}
+			}
+			if tt.check != nil {
+				tt.check(t, &gpx)
+			}
+		})
+	}
+}
+
+func TestParseFixtures(t *testing.T) {
+	runFixtures(t, []fixture{
+		{file: "selfclosing.gpx", opts: lenient, points: 4, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, nan, ""),
+			1: pt(60.2, 24.8, 12.5, "2024-05-01T10:00:01Z"),
+			2: pt(60.3, 24.7, nan, ""),
+			3: pt(60.4, 24.6, nan, ""),
+		}},
+		{file: "selfclosing.gpx", err: ErrMissingElevation},
+		{file: "scientific.gpx", points: 3, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, 615.25, ""),
+			1: pt(1.23e-4, -2.5e-3, -15, ""),
+			2: pt(60.1, 24.9, 1000, ""),
+		}},
+		{file: "singlequote.gpx", points: 3, xml: true, want: map[int]Trkpt{
+			0: pt(37.9, -5.7, 100, ""),
+			1: pt(37.91, -5.71, 101, ""),
+			2: pt(37.92, -5.72, 102, ""),
+		}},
+		{file: "bom.gpx", points: 2, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if gpx.Creator != "bom test" || gpx.Time != "2024-05-01T10:00:00Z" {
+				t.Errorf("header %q %q", gpx.Creator, gpx.Time)
+			}
+		}},
+		{file: "leadingspace.gpx", points: 1, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if gpx.Creator != "leading space test" || gpx.Trks[0].Name != "leading" {
+				t.Errorf("header %q %q", gpx.Creator, gpx.Trks[0].Name)
+			}
+		}},
+		{file: "crlf.gpx", points: 2, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, 12.5, "2024-05-01T10:00:00Z"),
+			1: pt(60.2, 24.8, 13, ""),
+		}},
+		{file: "short.gpx", points: 3, want: map[int]Trkpt{
+			0: pt(1, 2, 3, ""),
+			1: pt(0, 0, 0, ""),
+			2: pt(-1, -2, -3, ""),
+		}},
+		{file: "named.gpx", points: 1, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if name := gpx.Trks[0].Name; name != "Morning Ride & Run" {
+				t.Errorf("track name %q", name)
+			}
+		}},
+		{file: "entities.gpx", points: 1, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if gpx.Creator != "Café <GPS>" || gpx.Metadata.Author != `José "Pepe" O'Neil` ||
+				gpx.Metadata.Keywords != "ski, pisté" || gpx.Trks[0].Name != "Château d’Œx & Zürich" {
+				t.Errorf("text %q %q %q %q", gpx.Creator, gpx.Metadata.Author, gpx.Metadata.Keywords, gpx.Trks[0].Name)
+			}
+		}},
+		{file: "magvar.gpx", points: 3, xmlPts: true, want: map[int]Trkpt{
+			0: withExtra(pt(60.1, 24.9, 1, ""), map[string]float64{"magvar": 8.5, "geoidheight": 17.2}),
+			1: withExtra(pt(60.2, 24.8, 2, ""), map[string]float64{"geoidheight": -3.25}),
+			2: pt(60.3, 24.7, 3, ""),
+		}},
+		{file: "padded.gpx", points: 3, want: map[int]Trkpt{
+			0: pt(37.94, -5.7, 100, ""),
+			1: pt(37.95, -5.71, 101, ""),
+			2: pt(37.96, -5.72, 102, ""),
+		}},
+		{file: "eleorder.gpx", points: 3, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, 10, "2024-05-01T10:00:00Z"),
+			1: pt(60.2, 24.8, 11, ""),
+			2: pt(60.3, 24.7, 12, "2024-05-01T10:00:02Z"),
+		}},
+		{file: "gpx10.gpx", points: 1, check: func(t *testing.T, gpx *GPX) {
+			want := Metadata{Author: "Jane Doe", Keywords: "hiking",
+				Link: Link{Href: "https://example.com/track", Text: "Example track"}}
+			if gpx.Version != "1.0" || gpx.Time != "2024-05-01T10:00:00Z" || gpx.Metadata != want {
+				t.Errorf("header %q %q %v, want 1.0 %v", gpx.Version, gpx.Time, gpx.Metadata, want)
+			}
+		}},
+		{file: "comma.gpx", opts: ParseOptions{DecimalComma: true}, points: 2, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, 615.25, ""),
+			1: pt(60.2, 24.8, 616.5, ""),
+		}},
+		{file: "comma.gpx", err: ErrInvalidCoordinate},
+		{file: "comma.gpx", opts: ParseOptions{IgnoreErrors: true}, points: 1, errcnt: 1},
+		{file: "namespace.gpx", points: 2, xml: true,
+			want: map[int]Trkpt{
+				0: pt(60.1, 24.9, 1, "2024-05-01T10:00:00Z"),
+				1: pt(60.2, 24.8, 2, ""),
+			}},
+		{file: "latin1.gpx", points: 1, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if gpx.Creator != "Garmin € test" || gpx.Metadata.Author != "Jörg Müller" ||
+				gpx.Trks[0].Name != "Café “crème”" {
+				t.Errorf("text %q %q %q", gpx.Creator, gpx.Metadata.Author, gpx.Trks[0].Name)
+			}
+		}},
+		{file: "emptyele.gpx", opts: lenient, points: 4, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, nan, "2024-05-01T10:00:00Z"),
+			1: pt(60.2, 24.8, nan, ""),
+			2: pt(60.3, 24.7, nan, ""),
+			3: pt(60.4, 24.6, 4, ""),
+		}},
+		{file: "emptyele.gpx", err: ErrMissingElevation},
+		{file: "cmtdesc.gpx", points: 1, xml: true, check: func(t *testing.T, gpx *GPX) {
+			trk := gpx.Trks[0]
+			if trk.Cmt != "Steep & exposed after the hut" || trk.Desc != "Loop via the north ridge, 12 km <> 900 m" {
+				t.Errorf("cmt %q desc %q", trk.Cmt, trk.Desc)
+			}
+		}},
+		{file: "selfclosing.gpx", opts: lenient, points: 4, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if trk := gpx.Trks[0]; trk.Name != "" || trk.Cmt != "" || trk.Desc != "" {
+				t.Errorf("track text %q %q %q, want none", trk.Name, trk.Cmt, trk.Desc)
+			}
+		}},
+		{file: "dop.gpx", points: 4, xmlPts: true, want: map[int]Trkpt{
+			0: withExtra(pt(60.1, 24.9, 1, ""), map[string]float64{"sat": 9, "hdop": 0.9, "vdop": 1.4, "pdop": 1.7}),
+			1: withExtra(pt(60.2, 24.8, 2, ""), map[string]float64{"sat": 4, "hdop": 6.5}),
+			2: pt(60.3, 24.7, 3, ""),
+			3: withExtra(pt(60.4, 24.6, 4, ""), map[string]float64{"pdop": 2}),
+		}, check: func(t *testing.T, gpx *GPX) {
+			if n := gpx.FilterByHDOP(5); n != 1 || len(gpx.TrkpSlice()) != 3 {
+				t.Errorf("FilterByHDOP removed %d, %d points left, want 1, 3", n, len(gpx.TrkpSlice()))
+			}
+		}},
+		{file: "nanele.gpx", opts: lenient, points: 4, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, nan, ""),
+			1: pt(60.2, 24.8, nan, ""),
+			2: pt(60.3, 24.7, nan, ""),
+			3: pt(60.4, 24.6, 4, ""),
+		}},
+		{file: "nanele.gpx", err: ErrMissingElevation},
+		{file: "nancoord.gpx", err: ErrInvalidCoordinate},
+		{file: "nancoord.gpx", opts: ParseOptions{IgnoreErrors: true}, points: 1, errcnt: 2},
+		{file: "corrupted.gpx", err: ErrUnbalanced},
+		{file: "corrupted.gpx", opts: ParseOptions{IgnoreErrors: true}, points: 5, errcnt: 1, want: map[int]Trkpt{
+			1: pt(60.1001, 24.9001, 11, ""),
+			2: pt(60.1003, 24.9003, 13, ""),
+			4: pt(60.1005, 24.9005, 15, ""),
+		}},
+		{file: "embedded.xml", points: 2, xml: true, check: func(t *testing.T, gpx *GPX) {
+			if gpx.Creator != "embedded test" || gpx.Time != "2024-05-01T10:00:00Z" || gpx.Trks[0].Name != "embedded" {
+				t.Errorf("header %q %q %q", gpx.Creator, gpx.Time, gpx.Trks[0].Name)
+			}
+		}},
+		{file: "concatenated.gpx", points: 4, check: func(t *testing.T, gpx *GPX) {
+			if gpx.Creator != "log 1" || gpx.Trks[0].Name != "first" {
+				t.Errorf("header %q %q, want the first document", gpx.Creator, gpx.Trks[0].Name)
+			}
+		}},
+		{file: "emptyextra.gpx", points: 3, xmlPts: true, want: map[int]Trkpt{
+			0: pt(60.1, 24.9, 1, ""),
+			1: withExtra(pt(60.2, 24.8, 2, ""), map[string]float64{"cad": 85}),
+			2: withExtra(pt(60.3, 24.7, 3, ""), map[string]float64{"hdop": 1.5}),
+		}},
+	})
+}
+
+func TestParseDocuments(t *testing.T) {
+	tests := []struct {
+		data   []byte
+		names  []string
+		points []int
+		failed bool // a document fails to parse
+	}{
+		{readTestdata(t, "concatenated.gpx"), []string{"first", "second", "interrupted"}, []int{2, 1, 1}, false},
+		{readTestdata(t, "named.gpx"), []string{"Morning Ride & Run"}, []int{1}, false},
+		{append(readTestdata(t, "short.gpx"), `<gpx><trk><trkseg></trkseg></trk></gpx>`...),
+			[]string{"", ""}, []int{3, 0}, true},
+	}
+	for i, tt := range tests {
+		gpxs, err := ParseDocuments(tt.data, ParseOptions{})
+		if (err != nil) != tt.failed {
+			t.Errorf("%d: err = %v", i, err)
+		}
+		if len(gpxs) != len(tt.names) {
+			t.Fatalf("%d: %d documents, want %d", i, len(gpxs), len(tt.names))
+		}
+		for k, gpx := range gpxs {
+			name := ""
+			if len(gpx.Trks) > 0 {
+				name = gpx.Trks[0].Name
+			}
+			if name != tt.names[k] || len(gpx.TrkpSlice()) != tt.points[k] {
+				t.Errorf("%d: document %d: %q %d points, want %q %d", i, k+1, name,
+					len(gpx.TrkpSlice()), tt.names[k], tt.points[k])
+			}
+		}
+	}
+	if _, err := ParseDocuments([]byte("no documents"), ParseOptions{}); !errors.Is(err, ErrNoTrackpoints) {
+		t.Errorf("err = %v, want %v", err, ErrNoTrackpoints)
+	}
+}
+
+func TestZeroOptionsRequireElevation(t *testing.T) {
+	data := readTestdata(t, "selfclosing.gpx")
+	parsers := []struct {
+		name  string
+		parse func(opts ParseOptions) error
+	}{
+		{"Parse", func(opts ParseOptions) error { _, e := Parse(data, opts); return e }},
+		{"NewReader", func(opts ParseOptions) error { _, e := NewReader(bytes.NewReader(data), opts); return e }},
+		{"ParseGPXWithOptions", func(opts ParseOptions) error { return ParseGPXWithOptions(data, &GPX{}, opts) }},
+		{"ParseDocuments", func(opts ParseOptions) error { _, e := ParseDocuments(data, opts); return e }},
+		{"NewScannerWithOptions", func(opts ParseOptions) error {
+			_, _, e := NewScannerWithOptions(data, opts).Next()
+			return e
+		}},
+	}
+	for _, tt := range parsers {
+		if err := tt.parse(ParseOptions{}); !errors.Is(err, ErrMissingElevation) {
+			t.Errorf("%s: zero options: err = %v, want %v", tt.name, err, ErrMissingElevation)
+		}
+		if err := tt.parse(lenient); err != nil {
+			t.Errorf("%s: AllowMissingElevation: %v", tt.name, err)
+		}
+	}
+}
+
+func TestEmptyAccessors(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty.gpx", readTestdata(t, "empty.gpx")},
+		{"no data", nil},
+		{"not GPX", []byte("<html><body>not GPX</body></html>")},
+		{"invalid", []byte(`<gpx><trk><trkseg><trkpt lat="x" lon="y"></trkpt></trkseg></trk></gpx>`)},
+	}
+	for _, tt := range tests {
+		for _, gpx := range []*GPX{{}, NewEmpty()} {
+			if err := ParseGPX(tt.data, gpx, false); err == nil {
+				t.Errorf("%s: no error", tt.name)
+			}
+			if s := gpx.TrkpSlice(); s != nil {
+				t.Errorf("%s: TrkpSlice = %v, want nil", tt.name, s)
+			}
+			if s := gpx.TrkpSliceCopy(); len(s) != 0 {
+				t.Errorf("%s: TrkpSliceCopy = %v, want empty", tt.name, s)
+			}
+			gpx.TrkpSliceRelease()
+			if !gpx.IsEmpty() || gpx.TotalPoints() != 0 || gpx.Offsets() != nil {
+				t.Errorf("%s: IsEmpty %v, TotalPoints %d, Offsets %v", tt.name,
+					gpx.IsEmpty(), gpx.TotalPoints(), gpx.Offsets())
+			}
+			if _, ok := gpx.Track(0); ok {
+				t.Errorf("%s: Track(0) found", tt.name)
+			}
+			if d := gpx.Distance(); d != 0 {
+				t.Errorf("%s: Distance = %v, want 0", tt.name, d)
+			}
+			gpx.Compact()
+			gpx.Reset()
+		}
+	}
+}
+
+func TestCloneIndependent(t *testing.T) {
+	data := readTestdata(t, "multitrack.gpx")
+	for _, opts := range []ParseOptions{lenient, {UseXMLParser: true}} {
+		gpx, err := Parse(data, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orig, err := Parse(data, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := gpx.Clone()
+		c.Creator = "clone"
+		c.Metadata.Author = "clone"
+		for i := range c.Trks {
+			c.Trks[i].Name = "clone"
+			for j := range c.Trks[i].Trksegs {
+				s := c.Trks[i].Trksegs[j].Trkpts
+				for k := range s {
+					s[k].Lat, s[k].Ele = 0, 0
+					if s[k].Extra != nil {
+						s[k].Extra.HR = 0
+					}
+				}
+				c.Trks[i].Trksegs[j].Trkpts = append(s, Trkpt{})
+			}
+			c.Trks[i].Trksegs = append(c.Trks[i].Trksegs, Trkseg{})
+		}
+		c.Trks = append(c.Trks, Trk{})
+		if !sameGPX(gpx, orig) || sameGPX(c, orig) {
+			t.Errorf("UseXMLParser %v: original changed by modifying the clone", opts.UseXMLParser)
+		}
+	}
+}
+
+// sameGPX reports whether a and b have the same header, tracks,
+// segments and track points.
+func sameGPX(a, b *GPX) bool {
+	if a.Creator != b.Creator || a.Version != b.Version || a.Time != b.Time ||
+		a.Metadata != b.Metadata || len(a.Trks) != len(b.Trks) {
+		return false
+	}
+	for i, trk := range a.Trks {
+		u := b.Trks[i]
+		if trk.Name != u.Name || trk.Cmt != u.Cmt || trk.Desc != u.Desc || len(trk.Trksegs) != len(u.Trksegs) {
+			return false
+		}
+		for j, seg := range trk.Trksegs {
+			if len(seg.Trkpts) != len(u.Trksegs[j].Trkpts) {
+				return false
+			}
+			for k, p := range seg.Trkpts {
+				if !sameTrkpt(p, u.Trksegs[j].Trkpts[k]) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
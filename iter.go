@@ -0,0 +1,36 @@
+package gpx
+
+import "iter"
+
+// AllPoints returns an iterator over the track points of all tracks and
+// track segments in order. A GPX without segments yields nothing.
+func (gpx *GPX) AllPoints() iter.Seq[Trkpt] {
+	return func(yield func(Trkpt) bool) {
+		for _, trk := range gpx.Trks {
+			for _, seg := range trk.Trksegs {
+				for _, p := range seg.Trkpts {
+					if !yield(p) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Len returns the number of track points in s.
+func (s Trkseg) Len() int {
+	return len(s.Trkpts)
+}
+
+// EachSegment calls fn for each track segment of all tracks in order with
+// the indices of the track in gpx.Trks and of the segment in its Trksegs.
+// seg shares the track points of gpx. For a GPX without segments fn is
+// not called.
+func (gpx *GPX) EachSegment(fn func(trackIdx, segIdx int, seg Trkseg)) {
+	for i, trk := range gpx.Trks {
+		for j, seg := range trk.Trksegs {
+			fn(i, j, seg)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package gpx
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"time"
+)
+
+// MarshalJSON encodes gpx as JSON with the json tag names of the GPX
+// types, e.g. {"trks":[{"name":"x","trksegs":[{"trkpts":[{"lat":1,...}]}]}]}.
+// Empty metadata is omitted. Track points are encoded by Trkpt.MarshalJSON.
+func (gpx *GPX) MarshalJSON() ([]byte, error) {
+	type plain GPX // plain has no MarshalJSON method
+	v := struct {
+		*plain
+		Metadata *Metadata `json:"metadata,omitempty"`
+	}{plain: (*plain)(gpx)}
+	if gpx.Metadata != (Metadata{}) {
+		v.Metadata = &gpx.Metadata
+	}
+	return json.Marshal(v)
+}
+
+/*
+MarshalJSON encodes track point p compactly as JSON
+{"lat":37.942557,"lon":-5.760211,"ele":615.25,"time":"2023-06-19T11:27:32Z"}.
+Missing elevation and time are omitted and so are the missing TrkptExtra
+values, the present ones have their GPX element names, e.g. "magvar".
+JSON has no NaN, so a NaN or infinite lat or lon, e.g. of
+PartialCoordinates, is an ErrInvalidCoordinate error, which also fails
+GPX.MarshalJSON.
+*/
+func (p Trkpt) MarshalJSON() ([]byte, error) {
+	if !hasCoords(p) {
+		return nil, ErrInvalidCoordinate
+	}
+	b := make([]byte, 0, 96)
+	b = append(b, `{"lat":`...)
+	b = strconv.AppendFloat(b, p.Lat, 'f', -1, 64)
+	b = append(b, `,"lon":`...)
+	b = strconv.AppendFloat(b, p.Lon, 'f', -1, 64)
+	b = appendJSONFloat(b, "ele", p.Ele)
+	if !p.Time.IsZero() {
+		b = append(b, `,"time":"`...)
+		b = p.Time.UTC().AppendFormat(b, time.RFC3339Nano)
+		b = append(b, '"')
+	}
+	if x := p.Extra; x != nil {
+		for _, name := range extraNames {
+			b = appendJSONFloat(b, name, *x.field(name))
+		}
+	}
+	return append(b, '}'), nil
+}
+
+// appendJSONFloat appends ,"name":f to b, if f is not NaN.
+func appendJSONFloat(b []byte, name string, f float64) []byte {
+	if math.IsNaN(f) {
+		return b
+	}
+	b = append(b, `,"`...)
+	b = append(b, name...)
+	b = append(b, `":`...)
+	return strconv.AppendFloat(b, f, 'f', -1, 64)
+}
+
+/*
+UnmarshalJSON decodes track point p from JSON as encoded by
+Trkpt.MarshalJSON. An absent or null "ele" is a NaN Ele, an absent or
+null "time" a zero Time and the TrkptExtra values are decoded by their
+GPX element names, absent or null ones are NaN. Extra is nil, if no
+TrkptExtra value is present. Other names are ignored. An absent or null
+"lat" or "lon" is an ErrMissingLatitude or ErrMissingLongitude error.
+*/
+func (p *Trkpt) UnmarshalJSON(b []byte) error {
+	var m map[string]json.RawMessage
+	if e := json.Unmarshal(b, &m); e != nil {
+		return e
+	}
+	num := func(name string) (float64, bool, error) {
+		var f *float64
+		if v, found := m[name]; found {
+			if e := json.Unmarshal(v, &f); e != nil {
+				return math.NaN(), false, e
+			}
+		}
+		if f == nil {
+			return math.NaN(), false, nil
+		}
+		return *f, true, nil
+	}
+	var q Trkpt
+	var ok bool
+	var e error
+	if q.Lat, ok, e = num("lat"); e != nil {
+		return e
+	} else if !ok {
+		return ErrMissingLatitude
+	}
+	if q.Lon, ok, e = num("lon"); e != nil {
+		return e
+	} else if !ok {
+		return ErrMissingLongitude
+	}
+	if q.Ele, _, e = num("ele"); e != nil {
+		return e
+	}
+	if t, found := m["time"]; found {
+		if e = json.Unmarshal(t, &q.Time); e != nil {
+			return e
+		}
+	}
+	x := newTrkptExtra()
+	for _, name := range extraNames {
+		f, found, e := num(name)
+		if e != nil {
+			return e
+		}
+		if found {
+			*x.field(name) = f
+			q.Extra = &x
+		}
+	}
+	*p = q
+	return nil
+}
@@ -0,0 +1,62 @@
+package gpx
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, file := range []string{"multitrack.gpx", "dop.gpx", "magvar.gpx", "emptyele.gpx", "selfclosing.gpx"} {
+		gpx := parseTestdata(t, file)
+		b, err := json.Marshal(gpx)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		var r GPX
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatalf("%s: %v\n%s", file, err, b)
+		}
+		s, rs := gpx.TrkpSlice(), r.TrkpSlice()
+		if len(rs) != len(s) {
+			t.Fatalf("%s: %d points after round trip, want %d", file, len(rs), len(s))
+		}
+		for i := range s {
+			if !sameTrkpt(rs[i], s[i]) {
+				t.Errorf("%s: point %d: got %v %v, want %v %v", file, i, rs[i], rs[i].Extra, s[i], s[i].Extra)
+			}
+		}
+	}
+}
+
+func TestTrkptUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Trkpt
+		fail bool
+		err  error
+	}{
+		{`{"lat":1,"lon":2}`, pt(1, 2, nan, ""), false, nil},
+		{`{"lat":1,"lon":2,"ele":null,"time":null}`, pt(1, 2, nan, ""), false, nil},
+		{`{"lat":1,"lon":2,"ele":0,"time":"2023-06-19T11:27:32Z"}`, pt(1, 2, 0, "2023-06-19T11:27:32Z"), false, nil},
+		{`{"lat":1,"lon":2,"hr":120,"power":null,"name":"x"}`, withExtra(pt(1, 2, nan, ""), map[string]float64{"hr": 120}), false, nil},
+		{`{"lon":2}`, Trkpt{}, true, ErrMissingLatitude},
+		{`{"lat":1,"lon":null}`, Trkpt{}, true, ErrMissingLongitude},
+		{`{"lat":"1","lon":2}`, Trkpt{}, true, nil},
+		{`{"lat":1,"lon":2,"time":"noon"}`, Trkpt{}, true, nil},
+	}
+	for _, tt := range tests {
+		var p Trkpt
+		err := json.Unmarshal([]byte(tt.in), &p)
+		switch {
+		case tt.fail:
+			if err == nil || tt.err != nil && !errors.Is(err, tt.err) {
+				t.Errorf("%s: err = %v, want %v", tt.in, err, tt.err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", tt.in, err)
+		case !sameTrkpt(p, tt.want):
+			t.Errorf("%s: got %v %v, want %v %v", tt.in, p, p.Extra, tt.want, tt.want.Extra)
+		}
+	}
+}
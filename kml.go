@@ -0,0 +1,55 @@
+package gpx
+
+import "io"
+
+/*
+WriteKML writes gpx to w as a KML 2.2 Document with a Placemark with
+a LineString for each track segment. The Placemark name is the track
+name. Coordinates are lon,lat,ele triples, or lon,lat if elevation is
+missing. Times are not written. Points without valid lat and lon are
+skipped as in Marshal. Numbers are formatted by DefaultWriteOptions.
+*/
+func (gpx *GPX) WriteKML(w io.Writer) error {
+	return gpx.WriteKMLWithOptions(w, DefaultWriteOptions)
+}
+
+// WriteKMLWithOptions is like WriteKML, but numbers are formatted by opts.
+func (gpx *GPX) WriteKMLWithOptions(w io.Writer, opts WriteOptions) error {
+	bw := &bufWriter{w: w, b: make([]byte, 0, writeBufSize)}
+	bw.b = append(bw.b, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n"...)
+	bw.b = append(bw.b, "<kml xmlns=\"http://www.opengis.net/kml/2.2\">\n<Document>\n"...)
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			bw.b = append(bw.b, " <Placemark>\n"...)
+			if trk.Name != "" {
+				bw.b = append(bw.b, "  <name>"...)
+				bw.b = appendEscaped(bw.b, trk.Name)
+				bw.b = append(bw.b, "</name>\n"...)
+			}
+			bw.b = append(bw.b, "  <LineString><coordinates>\n"...)
+			for _, p := range seg.Trkpts {
+				if !hasCoords(p) {
+					continue
+				}
+				bw.b = appendKMLCoord(bw.b, p, opts)
+				bw.maybeFlush()
+			}
+			bw.b = append(bw.b, "  </coordinates></LineString>\n </Placemark>\n"...)
+		}
+	}
+	bw.b = append(bw.b, "</Document>\n</kml>\n"...)
+	return bw.flush()
+}
+
+// appendKMLCoord appends track point p as a KML coordinate line to b.
+func appendKMLCoord(b []byte, p Trkpt, opts WriteOptions) []byte {
+	b = append(b, "   "...)
+	b = appendFloat(b, p.Lon, opts.CoordDecimals)
+	b = append(b, ',')
+	b = appendFloat(b, p.Lat, opts.CoordDecimals)
+	if p.HasEle() {
+		b = append(b, ',')
+		b = appendFloat(b, p.Ele, opts.EleDecimals)
+	}
+	return append(b, '\n')
+}
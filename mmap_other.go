@@ -0,0 +1,10 @@
+//go:build !unix
+
+package gpx
+
+// NewMmap is NewWithOptions on systems without memory mapping support.
+// unmap is a no-op. See the Unix version.
+func NewMmap(name string, opts ParseOptions) (gpx *GPX, unmap func() error, err error) {
+	gpx, err = NewWithOptions(name, opts)
+	return gpx, func() error { return nil }, err
+}
@@ -0,0 +1,43 @@
+//go:build unix
+
+package gpx
+
+import (
+	"os"
+	"syscall"
+)
+
+/*
+NewMmap is like NewWithOptions, but the file is memory mapped instead of
+read to memory, so files larger than the available RAM can be parsed.
+The returned GPX does not refer to the mapped data, so unmap, which
+unmaps the file, can be called as soon as NewMmap returns. On error
+unmap is a no-op. Memory mapping is used on Unix systems only, elsewhere
+the file is read as in NewWithOptions.
+*/
+func NewMmap(name string, opts ParseOptions) (gpx *GPX, unmap func() error, err error) {
+	unmap = func() error { return nil }
+	f, e := os.Open(name)
+	if e != nil {
+		return &GPX{}, unmap, errf("%w", e)
+	}
+	defer f.Close()
+	fi, e := f.Stat()
+	if e != nil {
+		return &GPX{}, unmap, errf("%w", e)
+	}
+	if opts.tooLarge(fi.Size()) {
+		return &GPX{}, unmap, errf("%s: %w", name, ErrTooLarge)
+	}
+	if fi.Size() == 0 {
+		gpx, e = newGPX(name, nil, opts)
+		return gpx, unmap, e
+	}
+	data, e := syscall.Mmap(int(f.Fd()), 0, int(fi.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if e != nil {
+		return &GPX{}, unmap, errf("%s: mmap: %w", name, e)
+	}
+	unmap = func() error { return syscall.Munmap(data) }
+	gpx, e = newGPX(name, data, opts)
+	return gpx, unmap, e
+}
@@ -0,0 +1,94 @@
+package gpx
+
+import "math"
+
+// NearestPoint returns the index of the track point of the first track
+// segment nearest to (lat, lon) and its haversine distance in meters.
+// For an empty track the index is -1.
+func (gpx *GPX) NearestPoint(lat, lon float64) (index int, distance float64) {
+	index = -1
+	for i, p := range gpx.firstTrkpts() {
+		d := Haversine(lat, lon, p.Lat, p.Lon)
+		if index < 0 || d < distance {
+			index, distance = i, d
+		}
+	}
+	return index, distance
+}
+
+/*
+SnapToTrack returns the point on the first track segment polyline nearest
+to (lat, lon), its cumulative distance along the track and the distance
+from (lat, lon) to it, both in meters. The nearest point may be anywhere
+on an edge between consecutive track points, not only a track point as
+in NearestPoint. The edges are projected to a local plane centered at
+(lat, lon), which is accurate for edges of some kilometers or less. For
+an empty track the distances are NaN.
+*/
+func (gpx *GPX) SnapToTrack(lat, lon float64) (snapped Trkpt, distAlong, perpDist float64) {
+	s := gpx.firstTrkpts()
+	switch len(s) {
+	case 0:
+		return Trkpt{}, math.NaN(), math.NaN()
+	case 1:
+		return s[0], 0, Haversine(lat, lon, s[0].Lat, s[0].Lon)
+	}
+	best, bestT, bestD := 1, 0.0, math.Inf(1)
+	ax, ay := localXY(lat, lon, s[0])
+	for i := 1; i < len(s); i++ {
+		bx, by := localXY(lat, lon, s[i])
+		t, d := projectOrigin(ax, ay, bx, by)
+		if d < bestD {
+			best, bestT, bestD = i, t, d
+		}
+		ax, ay = bx, by
+	}
+	for i := 1; i < best; i++ {
+		distAlong += dist(s[i-1], s[i])
+	}
+	distAlong += bestT * dist(s[best-1], s[best])
+	snapped = interpolate(s[best-1], s[best], bestT)
+	return snapped, distAlong, Haversine(lat, lon, snapped.Lat, snapped.Lon)
+}
+
+// localXY returns the equirectangular projection of p in meters to a
+// plane with origin at (lat0, lon0), x to east and y to north.
+func localXY(lat0, lon0 float64, p Trkpt) (x, y float64) {
+	const rad = math.Pi / 180
+	x = lonDelta(lon0, p.Lon) * rad * EarthRadius * math.Cos(lat0*rad)
+	y = (p.Lat - lat0) * rad * EarthRadius
+	return x, y
+}
+
+// projectOrigin returns the fraction t in [0, 1] of the point on the
+// segment from a to b nearest to the origin and the distance to it.
+func projectOrigin(ax, ay, bx, by float64) (t, d float64) {
+	dx, dy := bx-ax, by-ay
+	if l2 := dx*dx + dy*dy; l2 > 0 {
+		t = min(max(-(ax*dx+ay*dy)/l2, 0), 1)
+	}
+	return t, math.Hypot(ax+t*dx, ay+t*dy)
+}
+
+/*
+MatchRoute compares the first track segment of gpx, e.g. a ride, against
+the first track segment of a planned route. It returns the fraction of
+the track points within corridorMeters of the route polyline, measured
+as in SnapToTrack, and the indices of the other, off-route, points. The
+direction of travel is not checked, riding the route backwards or in
+another order of its parts is on-route too. The cost is O(n·m) for
+tracks of n and m points. An empty track has coverage NaN, and with an
+empty route all points are off-route.
+*/
+func (gpx *GPX) MatchRoute(route *GPX, corridorMeters float64) (coverage float64, offRoute []int) {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return math.NaN(), nil
+	}
+	for i, p := range s {
+		if _, _, d := route.SnapToTrack(p.Lat, p.Lon); !(d <= corridorMeters) { //NaN for an empty route
+			offRoute = append(offRoute, i)
+		}
+	}
+	return float64(len(s)-len(offRoute)) / float64(len(s)), offRoute
+}
@@ -0,0 +1,145 @@
+package gpx
+
+import (
+	"runtime"
+	"sync"
+)
+
+var trksegtag = []byte("<trkseg")
+
+// chunk is a part of GPX data parsed by a single ParseGPXParallel worker.
+type chunk struct {
+	data   []byte
+	trkpts []Trkpt
+	errcnt int
+	err    error
+}
+
+/*
+ParseGPXParallel parses GPX data like ParseGPX, but track segments are
+parsed concurrently by workers goroutines. The data is split at <trkseg
+tags, so a file with a single track segment is parsed by one goroutine.
+The chunk results are merged in order to a single track segment as in
+ParseGPX. The header and the first track name, comment and description
+are parsed from the data before the first chunk as in ParseGPX. If
+workers <= 0, runtime.GOMAXPROCS(0) workers are used.
+*/
+func ParseGPXParallel(gpxbytes []byte, workers int, ignoreErrors bool) (*GPX, error) {
+	gpx := &GPX{}
+	chunks := splitTrksegs(gpxbytes)
+	data := trimBOM(gpxbytes)
+	head := gpxRoot(textUTF8(data, data[:max(cap(data)-cap(chunks[0].data), 0)]))
+	gpx.parseHeader(head)
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	if workers > len(chunks) {
+		workers = len(chunks)
+	}
+	jobs := make(chan *chunk, len(chunks))
+	for i := range chunks {
+		jobs <- &chunks[i]
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for c := range jobs {
+				c.parse(gpxbytes, ignoreErrors)
+			}
+		}()
+	}
+	wg.Wait()
+
+	points := 0
+	for i := range chunks {
+		c := &chunks[i]
+		gpx.errcnt += c.errcnt
+		if c.err != nil {
+			return gpx, errf("trackpoint %d: %w", points+len(c.trkpts)+1, c.err)
+		}
+		points += len(c.trkpts)
+	}
+	if points == 0 {
+		return gpx, ErrNoTrackpoints
+	}
+	trkseg, _ := makeTrkseg(points, gpx)
+	trk := &gpx.Trks[0]
+	trk.Name, trk.Cmt, trk.Desc = trackText(head)
+	for i := range chunks {
+		*trkseg = append(*trkseg, chunks[i].trkpts...)
+	}
+	return gpx, nil
+}
+
+/*
+ParseFiles parses GPX files names by NewWithOptions with workers
+goroutines, so at most workers files are in memory at the same time.
+The results and errors are in the order of names, errs[i] is nil if
+names[i] was parsed successfully. If workers <= 0,
+runtime.GOMAXPROCS(0) workers are used.
+*/
+func ParseFiles(names []string, opts ParseOptions, workers int) (gpxs []*GPX, errs []error) {
+	gpxs = make([]*GPX, len(names))
+	errs = make([]error, len(names))
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(names))
+	jobs := make(chan int, len(names))
+	for i := range names {
+		jobs <- i
+	}
+	close(jobs)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				gpxs[j], errs[j] = NewWithOptions(names[j], opts)
+			}
+		}()
+	}
+	wg.Wait()
+	return gpxs, errs
+}
+
+// parse parses track points of chunk c of GPX data gpxbytes.
+func (c *chunk) parse(gpxbytes []byte, ignoreErrors bool) {
+	p := &parser{
+		opts: ParseOptions{IgnoreErrors: ignoreErrors},
+		data: gpxbytes,
+	}
+	p.setTags()
+	b, e := p.selectTrkSegment(c.data)
+	if e != nil {
+		return //no track points in chunk
+	}
+	c.trkpts = make([]Trkpt, 0, p.init(b))
+	c.errcnt, c.err = p.parseTrkpts(b, &c.trkpts)
+}
+
+// splitTrksegs splits gpxbytes to chunks starting at <trkseg tags.
+// Data before the first <trkseg is dropped, if there is any <trkseg,
+// otherwise the single chunk starts at the first <trkpt.
+func splitTrksegs(gpxbytes []byte) []chunk {
+	var chunks []chunk
+
+	b := gpxbytes
+	l := indexTag(b, trksegtag)
+	if l < 0 {
+		return []chunk{{data: b[max(indexTag(b, starttag), 0):]}}
+	}
+	for {
+		d := indexTag(b[l+len(trksegtag):], trksegtag)
+		if d < 0 {
+			return append(chunks, chunk{data: b[l:]})
+		}
+		r := l + len(trksegtag) + d
+		chunks = append(chunks, chunk{data: b[l:r]})
+		l = r
+	}
+}
@@ -0,0 +1,76 @@
+package gpx
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// genSegments returns a GPX document of a named track with segs track
+// segments of n track points each.
+func genSegments(segs, n int) []byte {
+	var b bytes.Buffer
+	b.WriteString(`<?xml version="1.0"?><gpx version="1.1" creator="gpx test">` +
+		"<metadata><time>2024-05-01T10:00:00Z</time></metadata><trk><name>segments</name>\n")
+	for s := range segs {
+		b.WriteString("<trkseg>\n")
+		for i := range n {
+			fmt.Fprintf(&b, `<trkpt lat="%.5f" lon="%.5f"><ele>%d</ele></trkpt>`+"\n",
+				60+float64(i)/1e4, 24+float64(s)/1e3, i%100)
+		}
+		b.WriteString("</trkseg>\n")
+	}
+	b.WriteString("</trk></gpx>\n")
+	return b.Bytes()
+}
+
+func TestParseGPXParallel(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"segments", genSegments(8, 100)},
+		{"gpx10.gpx", readTestdata(t, "gpx10.gpx")},
+		{"bom.gpx", readTestdata(t, "bom.gpx")},
+		{"entities.gpx", readTestdata(t, "entities.gpx")},
+		{"cmtdesc.gpx", readTestdata(t, "cmtdesc.gpx")},
+		{"no trkseg", []byte(`<gpx creator="x"><trk><name>n</name><trkpt lat="1" lon="2"><ele>3</ele></trkpt></trk></gpx>`)},
+	}
+	for _, tt := range tests {
+		var want GPX
+		if err := ParseGPX(tt.data, &want, false); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		for _, workers := range []int{0, 1, 3} {
+			gpx, err := ParseGPXParallel(tt.data, workers, false)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+			if !sameGPX(gpx, &want) {
+				t.Errorf("%s: %d workers: ParseGPXParallel differs from ParseGPX: %q %q %v",
+					tt.name, workers, gpx.Creator, gpx.Trks[0].Name, gpx.Metadata)
+			}
+		}
+	}
+}
+
+func BenchmarkParseGPXSerial(b *testing.B) {
+	data := genSegments(16, 5000)
+	b.SetBytes(int64(len(data)))
+	for range b.N {
+		var gpx GPX
+		if err := ParseGPX(data, &gpx, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseGPXParallel(b *testing.B) {
+	data := genSegments(16, 5000)
+	b.SetBytes(int64(len(data)))
+	for range b.N {
+		if _, err := ParseGPXParallel(data, 0, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package gpx
+
+import (
+	"math"
+	"strings"
+)
+
+/*
+FromPolyline returns a GPX with a single track segment decoded from
+an encoded polyline, as used by Google and Mapbox. precision is the
+number of decimals of the encoded coordinates, usually 5 or 6 (Polyline6).
+Track points have no elevation or time. Decoding stops at invalid data.
+*/
+func FromPolyline(encoded string, precision int) *GPX {
+	gpx := &GPX{}
+	trkseg := gpx.trkseg()
+	factor := math.Pow10(precision)
+	lat, lon := 0, 0
+	for i := 0; i < len(encoded); {
+		dlat, n := polylineValue(encoded[i:])
+		if n == 0 {
+			break
+		}
+		i += n
+		dlon, n := polylineValue(encoded[i:])
+		if n == 0 {
+			break
+		}
+		i += n
+		lat += dlat
+		lon += dlon
+		trkseg.Trkpts = append(trkseg.Trkpts, Trkpt{
+			Lat: float64(lat) / factor,
+			Lon: float64(lon) / factor,
+			Ele: math.NaN(),
+		})
+	}
+	return gpx
+}
+
+// polylineValue decodes the first value of s and returns it and
+// its length in bytes, 0 if s does not start with a valid value.
+func polylineValue(s string) (v, n int) {
+	shift := uint(0)
+	u := 0
+	for n < len(s) {
+		c := int(s[n]) - 63
+		n++
+		if c < 0 || c > 63 || shift > 60 {
+			return 0, 0
+		}
+		u |= (c & 0x1f) << shift
+		shift += 5
+		if c < 0x20 {
+			if u&1 != 0 {
+				return ^(u >> 1), n
+			}
+			return u >> 1, n
+		}
+	}
+	return 0, 0
+}
+
+// Polyline returns the first track segment as an encoded polyline
+// with precision decimals, usually 5 or 6. Elevation and time are dropped
+// and points without valid lat and lon are skipped as in Marshal.
+func (gpx *GPX) Polyline(precision int) string {
+	var b strings.Builder
+
+	factor := math.Pow10(precision)
+	lat, lon := 0, 0
+	for _, p := range gpx.firstTrkpts() {
+		if !hasCoords(p) {
+			continue
+		}
+		plat := int(math.Round(p.Lat * factor))
+		plon := int(math.Round(p.Lon * factor))
+		appendPolylineValue(&b, plat-lat)
+		appendPolylineValue(&b, plon-lon)
+		lat, lon = plat, plon
+	}
+	return b.String()
+}
+
+// appendPolylineValue appends encoded value v to b.
+func appendPolylineValue(b *strings.Builder, v int) {
+	u := v << 1
+	if v < 0 {
+		u = ^u
+	}
+	for u >= 0x20 {
+		b.WriteByte(byte(0x20|u&0x1f) + 63)
+		u >>= 5
+	}
+	b.WriteByte(byte(u) + 63)
+}
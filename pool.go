@@ -0,0 +1,42 @@
+package gpx
+
+import "sync"
+
+var gpxPool = sync.Pool{New: func() any { return &GPX{} }}
+
+// AcquireGPX returns an empty GPX from a pool. When many files are parsed,
+// ParseGPX to a GPX from AcquireGPX reuses the track point capacity of
+// a GPX returned to the pool by Release.
+func AcquireGPX() *GPX {
+	return gpxPool.Get().(*GPX)
+}
+
+// Release zeroes gpx and returns it to the pool of AcquireGPX keeping the
+// capacity of the first track segment. gpx and any slices from it
+// (e.g. TrkpSlice) must not be used after Release.
+func (gpx *GPX) Release() {
+	clear(gpx.firstTrkpts())
+	gpx.Reset()
+	gpxPool.Put(gpx)
+}
+
+// Reset empties gpx for reuse. The first track segment is truncated to
+// length 0 keeping its capacity and the track and segment slices, which
+// ParseGPX reuses, so that parsing to the same gpx after Reset allocates
+// no track points, when the capacity is large enough. Slices from gpx
+// (e.g. TrkpSlice) must not be used after Reset, their contents are
+// overwritten by the next parse.
+func (gpx *GPX) Reset() {
+	s := gpx.firstTrkpts()
+	trks := gpx.Trks
+	*gpx = GPX{}
+	if cap(s) == 0 {
+		return
+	}
+	segs := trks[0].Trksegs
+	clear(trks[1:])
+	clear(segs[1:])
+	segs[0] = Trkseg{Trkpts: s[:0]}
+	trks[0] = Trk{Trksegs: segs[:1]}
+	gpx.Trks = trks[:1]
+}
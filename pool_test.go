@@ -0,0 +1,68 @@
+package gpx
+
+import "testing"
+
+func TestResetReusesTrkpts(t *testing.T) {
+	data := genTrack(0, 0, 1000)
+	var gpx GPX
+	for i := range 3 {
+		if err := ParseGPX(data, &gpx, false); err != nil {
+			t.Fatal(err)
+		}
+		s := gpx.TrkpSlice()
+		gpx.Reset()
+		if err := ParseGPX(data, &gpx, false); err != nil {
+			t.Fatal(err)
+		}
+		if &gpx.TrkpSlice()[0] != &s[0] {
+			t.Errorf("parse %d after Reset: track points not reused", i+1)
+		}
+		gpx.Reset()
+	}
+}
+
+func TestReleaseZeroes(t *testing.T) {
+	gpx := AcquireGPX()
+	if err := ParseGPX(genTrack(0, 0, 100), gpx, false); err != nil {
+		t.Fatal(err)
+	}
+	s := gpx.TrkpSlice()
+	gpx.Release()
+	if s[0] != (Trkpt{}) {
+		t.Errorf("track point not zeroed after Release: %v", s[0])
+	}
+}
+
+func BenchmarkParseGPXFresh(b *testing.B) {
+	data := genTrack(0, 0, 1000)
+	b.ReportAllocs()
+	for range b.N {
+		if err := ParseGPX(data, &GPX{}, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkParseGPXPool(b *testing.B) {
+	data := genTrack(0, 0, 1000)
+	b.ReportAllocs()
+	for range b.N {
+		gpx := AcquireGPX()
+		if err := ParseGPX(data, gpx, false); err != nil {
+			b.Fatal(err)
+		}
+		gpx.Release()
+	}
+}
+
+func BenchmarkParseGPXReset(b *testing.B) {
+	data := genTrack(0, 0, 1000)
+	var gpx GPX
+	b.ReportAllocs()
+	for range b.N {
+		gpx.Reset()
+		if err := ParseGPX(data, &gpx, false); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package gpx
+
+import "math"
+
+/*
+ElevationProfile returns at most maxPoints track points of the first
+track segment for an elevation profile chart. Track points without
+elevation are dropped. The points are selected by the Largest Triangle
+Three Buckets (LTTB) algorithm with cumulative distance as x and
+elevation as y: the points between the first and the last point are
+split to maxPoints-2 buckets of equal point count and from each bucket
+is selected the point with the largest triangle area with the point
+selected from the previous bucket and the average of the next bucket.
+This favours local minima and maxima. The first and the last point are
+always kept and so are the lowest and the highest point, unless both are
+in the same bucket, when the highest point is kept. If maxPoints is
+less than 3, 3 is used. The returned slice is new.
+*/
+func (gpx *GPX) ElevationProfile(maxPoints int) []Trkpt {
+	var s []Trkpt
+
+	for _, p := range gpx.firstTrkpts() {
+		if !math.IsNaN(p.Ele) {
+			s = append(s, p)
+		}
+	}
+	maxPoints = max(maxPoints, 3)
+	n := len(s)
+	if n <= maxPoints {
+		return s
+	}
+	x := cumDistances(s)
+	buckets := maxPoints - 2
+	every := float64(n-2) / float64(buckets)
+	start := func(i int) int { return min(int(float64(i)*every)+1, n-1) }
+
+	idx := make([]int, 0, maxPoints)
+	idx = append(idx, 0)
+	a := 0
+	for i := 0; i < buckets; i++ {
+		l, r := start(i), start(i+1)
+		nl, nr := r, max(start(i+2), r+1) //next bucket, the last point after the last bucket
+		if i == buckets-1 {
+			nl, nr = n-1, n
+		}
+		ax, ay := 0.0, 0.0
+		for j := nl; j < nr; j++ {
+			ax += x[j]
+			ay += s[j].Ele
+		}
+		ax /= float64(nr - nl)
+		ay /= float64(nr - nl)
+		best, area := l, -1.0
+		for j := l; j < r; j++ {
+			t := math.Abs((x[a]-ax)*(s[j].Ele-s[a].Ele) - (x[a]-x[j])*(ay-s[a].Ele))
+			if t > area {
+				best, area = j, t
+			}
+		}
+		idx = append(idx, best)
+		a = best
+	}
+	idx = append(idx, n-1)
+	keepExtremes(s, idx, every)
+
+	out := make([]Trkpt, len(idx))
+	for i, j := range idx {
+		out[i] = s[j]
+	}
+	return out
+}
+
+// keepExtremes replaces the selected points idx of the buckets of the
+// lowest and the highest point of s by them. idx[i+1] is the selected
+// point of bucket i, bucket i starting at index int(i*every)+1.
+func keepExtremes(s []Trkpt, idx []int, every float64) {
+	lo, hi := 0, 0
+	for j := range s {
+		if s[j].Ele < s[lo].Ele {
+			lo = j
+		}
+		if s[j].Ele > s[hi].Ele {
+			hi = j
+		}
+	}
+	for _, k := range []int{lo, hi} {
+		if k == 0 || k == len(s)-1 {
+			continue
+		}
+		i := min(int(float64(k-1)/every), len(idx)-3)
+		for i > 0 && int(float64(i)*every)+1 > k {
+			i--
+		}
+		for i < len(idx)-3 && int(float64(i+1)*every)+1 <= k {
+			i++
+		}
+		idx[i+1] = k
+	}
+}
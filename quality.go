@@ -0,0 +1,126 @@
+package gpx
+
+import "math"
+
+// Quality is the data quality breakdown of a track. The components are in
+// [0, 1], 1 is the best, and NaN if the track has no data for them.
+type Quality struct {
+	Score      float64 // mean of the other components, which are not NaN
+	HDOP       float64 // mean of min(1, 1/hdop) of the points with <hdop>
+	Regularity float64 // fraction of time intervals within 50% of the median interval
+	Spikes     float64 // 1 - 10 × spike fraction, at least 0
+	Density    float64 // min(1, 10 m / median distance between points)
+}
+
+// Quality score parameters.
+const (
+	qualitySpikeSpeed   = 50.0 // m/s, a spike with times
+	qualitySpikeSpacing = 10.0 // × median distance, a spike without times
+	qualityDenseSpacing = 10.0 // meters, Density is 1 at this spacing or less
+)
+
+// QualityScore returns Quality().Score.
+func (gpx *GPX) QualityScore() float64 {
+	return gpx.Quality().Score
+}
+
+/*
+Quality returns the data quality of the first track segment, e.g. to rank
+uploads. The components are
+
+  - HDOP: the mean of min(1, 1/hdop) over the points with <hdop>, so hdop
+    1 or less scores 1 and hdop 5 scores 0.2. NaN if no point has hdop.
+  - Regularity: the fraction of the time intervals between consecutive
+    points with time, which are within 50% of their median (see
+    SampleInterval). NaN if there are no such intervals.
+  - Spikes: 1 - 10 × the fraction of spike points, at least 0, so 10%
+    spikes scores 0. An interior point is a spike, if it jumps from both
+    neighbours: with times at over 50 m/s, otherwise over 10 times the
+    median distance between points. NaN for less than 3 points.
+  - Density: min(1, 10 m / MedianSpacing), 1 for a standing track.
+    NaN for less than 2 points.
+
+Score is the mean of the components, which are not NaN, so missing time
+or hdop only drops their components. Score is 0 if all components are NaN,
+e.g. for an empty track.
+*/
+func (gpx *GPX) Quality() Quality {
+	s := gpx.firstTrkpts()
+	q := Quality{HDOP: hdopQuality(s), Regularity: math.NaN(), Spikes: math.NaN(), Density: math.NaN()}
+	if median, e := gpx.SampleInterval(); e == nil {
+		q.Regularity = regularity(s, float64(median))
+	}
+	if len(s) >= 2 {
+		spacing := gpx.MedianSpacing()
+		q.Density = 1
+		if spacing > qualityDenseSpacing {
+			q.Density = qualityDenseSpacing / spacing
+		}
+		if len(s) >= 3 {
+			q.Spikes = max(1-10*float64(spikeCount(s, spacing))/float64(len(s)), 0)
+		}
+	}
+	sum, n := 0.0, 0
+	for _, f := range []float64{q.HDOP, q.Regularity, q.Spikes, q.Density} {
+		if !math.IsNaN(f) {
+			sum += f
+			n++
+		}
+	}
+	if n > 0 {
+		q.Score = sum / float64(n)
+	}
+	return q
+}
+
+// hdopQuality returns the HDOP component of Quality of s.
+func hdopQuality(s []Trkpt) float64 {
+	sum, n := 0.0, 0
+	for _, p := range s {
+		if p.Extra != nil && !math.IsNaN(p.Extra.HDOP) {
+			sum += min(1, 1/p.Extra.HDOP) //1 for hdop 0
+			n++
+		}
+	}
+	if n == 0 {
+		return math.NaN()
+	}
+	return sum / float64(n)
+}
+
+// regularity returns the Regularity component of Quality of s with
+// median time interval median nanoseconds.
+func regularity(s []Trkpt, median float64) float64 {
+	regular, n := 0, 0
+	for i := 1; i < len(s); i++ {
+		if s[i-1].Time.IsZero() || s[i].Time.IsZero() {
+			continue
+		}
+		dt := float64(s[i].Time.Sub(s[i-1].Time))
+		if math.Abs(dt-median) <= median/2 {
+			regular++
+		}
+		n++
+	}
+	return float64(regular) / float64(n)
+}
+
+// spikeCount returns the count of the spike points of s as in Quality
+// with median distance spacing between points.
+func spikeCount(s []Trkpt, spacing float64) int {
+	jump := func(p, q Trkpt) bool {
+		d := dist(p, q)
+		if p.Time.IsZero() || q.Time.IsZero() {
+			return d > qualitySpikeSpacing*spacing
+		}
+		dt := q.Time.Sub(p.Time).Seconds()
+		return d > qualitySpikeSpeed*max(dt, 0)
+	}
+	n := 0
+	for i := 1; i < len(s)-1; i++ {
+		if jump(s[i-1], s[i]) && jump(s[i], s[i+1]) {
+			n++
+		}
+	}
+	return n
+}
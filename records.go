@@ -0,0 +1,51 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// Record is a flat row of a track point with derived values, e.g. for
+// data frames and FIT converters. Missing values are NaN and a missing
+// Time is zero, as in Trkpt and TrkptExtra.
+type Record struct {
+	Lat, Lon, Ele float64
+	Time          time.Time
+	HR, Cad       float64 // beats and revolutions per minute
+	Power         float64 // watts
+	Distance      float64 // cumulative haversine distance in meters from the first point
+	Speed         float64 // m/s from the previous point, NaN without time or dt <= 0
+}
+
+// Records returns a Record of each track point of the first track
+// segment. The first point has Distance 0 and Speed NaN. An empty track
+// gives nil.
+func (gpx *GPX) Records() []Record {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return nil
+	}
+	nan := math.NaN()
+	recs := make([]Record, len(s))
+	d := 0.0
+	for i, p := range s {
+		r := Record{Lat: p.Lat, Lon: p.Lon, Ele: p.Ele, Time: p.Time,
+			HR: nan, Cad: nan, Power: nan, Speed: nan}
+		if x := p.Extra; x != nil {
+			r.HR, r.Cad, r.Power = x.HR, x.Cad, x.Power
+		}
+		if i > 0 {
+			q := s[i-1]
+			step := dist(q, p)
+			d += step
+			if !q.Time.IsZero() && !p.Time.IsZero() {
+				if dt := p.Time.Sub(q.Time).Seconds(); dt > 0 {
+					r.Speed = step / dt
+				}
+			}
+		}
+		r.Distance = d
+		recs[i] = r
+	}
+	return recs
+}
@@ -0,0 +1,65 @@
+package gpx
+
+// RepairOptions selects the fixes of Repair.
+type RepairOptions struct {
+	DropInvalid    bool    // drop invalid track points instead of failing
+	Dedupe         bool    // RemoveDuplicates
+	FixTime        bool    // FixTimeMonotonic
+	ClampElevation bool    // ClampElevation(MinElevation, MaxElevation)
+	MinElevation   float64 // zero MinElevation and MaxElevation are the defaults
+	MaxElevation   float64
+	FillElevation  bool // FillElevationGaps
+}
+
+// DefaultRepairOptions has all fixes of Repair with the default
+// elevation bounds.
+var DefaultRepairOptions = RepairOptions{
+	DropInvalid:    true,
+	Dedupe:         true,
+	FixTime:        true,
+	ClampElevation: true,
+	FillElevation:  true,
+}
+
+/*
+Repair returns GPX data in fixed and rewritten as GPX 1.1 data. The fixes
+are, in order:
+
+ 1. Parse in by the fast parser, which skips a byte order mark and accepts
+    both quote marks and track points without elevation. With
+    opts.DropInvalid invalid track points are dropped, as with IgnoreErrors,
+    otherwise the first one is an error. All track points are merged to
+    one track segment.
+ 2. opts.Dedupe: RemoveDuplicates removes repeated points.
+ 3. opts.FixTime: FixTimeMonotonic removes points going back in time.
+ 4. opts.ClampElevation: ClampElevation clamps garbage elevations to
+    opts.MinElevation and opts.MaxElevation, or to DefaultMinElevation and
+    DefaultMaxElevation, if both are zero.
+ 5. opts.FillElevation: FillElevationGaps fills missing elevations.
+ 6. Marshal writes the result with double quotes and escaped text.
+
+The header data, the track name and the TrkptExtra values are kept.
+*/
+func Repair(in []byte, opts RepairOptions) ([]byte, error) {
+	gpx, e := Parse(in, ParseOptions{IgnoreErrors: opts.DropInvalid, AllowMissingElevation: true})
+	if e != nil {
+		return nil, e
+	}
+	if opts.Dedupe {
+		gpx.RemoveDuplicates()
+	}
+	if opts.FixTime {
+		gpx.FixTimeMonotonic()
+	}
+	if opts.ClampElevation {
+		lo, hi := opts.MinElevation, opts.MaxElevation
+		if lo == 0 && hi == 0 {
+			lo, hi = DefaultMinElevation, DefaultMaxElevation
+		}
+		gpx.ClampElevation(lo, hi)
+	}
+	if opts.FillElevation {
+		gpx.FillElevationGaps()
+	}
+	return gpx.Marshal()
+}
@@ -0,0 +1,157 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// interpolate returns the track point at fraction f from p to q.
+// Lat, lon, ele and time, if both points have it, are interpolated linearly.
+// Lon is interpolated the shorter way, also across the antimeridian.
+func interpolate(p, q Trkpt, f float64) Trkpt {
+	lon := p.Lon + f*lonDelta(p.Lon, q.Lon)
+	if lon < -180 || lon >= 180 {
+		lon = normLon(lon)
+	}
+	t := Trkpt{
+		Lat: p.Lat + f*(q.Lat-p.Lat),
+		Lon: lon,
+		Ele: p.Ele + f*(q.Ele-p.Ele),
+	}
+	if !p.Time.IsZero() && !q.Time.IsZero() {
+		t.Time = p.Time.Add(time.Duration(f * float64(q.Time.Sub(p.Time))))
+	}
+	return t
+}
+
+// slerp returns the track point at fraction f from p to q along their
+// great-circle arc. Ele and time are interpolated as in interpolate, and
+// so are lat and lon of (nearly) equal or antipodal points.
+func slerp(p, q Trkpt, f float64) Trkpt {
+	const rad = math.Pi / 180
+	t := interpolate(p, q, f)
+	ax, ay, az := unitVector(p)
+	bx, by, bz := unitVector(q)
+	omega := math.Acos(min(max(ax*bx+ay*by+az*bz, -1), 1))
+	sin := math.Sin(omega)
+	if sin < 1e-12 {
+		return t
+	}
+	fa, fb := math.Sin((1-f)*omega)/sin, math.Sin(f*omega)/sin
+	x, y, z := fa*ax+fb*bx, fa*ay+fb*by, fa*az+fb*bz
+	t.Lat = math.Atan2(z, math.Hypot(x, y)) / rad
+	t.Lon = normLon(math.Atan2(y, x) / rad)
+	return t
+}
+
+// unitVector returns the unit vector from the earth center to p.
+func unitVector(p Trkpt) (x, y, z float64) {
+	const rad = math.Pi / 180
+	la, lo := p.Lat*rad, p.Lon*rad
+	return math.Cos(la) * math.Cos(lo), math.Cos(la) * math.Sin(lo), math.Sin(la)
+}
+
+/*
+Resample returns the first track segment resampled to track points
+spacingMeters apart along the track. The new points are interpolated
+linearly between the original points, which is a good approximation for
+short spacings. The first and the last track points are preserved, so the
+last spacing is usually shorter. If spacingMeters <= 0, nil is returned.
+*/
+func (gpx *GPX) Resample(spacingMeters float64) []Trkpt {
+	return gpx.resample(spacingMeters, interpolate)
+}
+
+/*
+ResampleGreatCircle is like Resample, but lat and lon of the new points
+are interpolated along the great-circle arc between the original points
+by spherical linear interpolation (slerp) of their unit vectors. Ele and
+time are interpolated linearly as in Resample. The points are then on
+the track as measured by the haversine distances, which matters for
+edges of hundreds of kilometers, e.g. of flights, and at high latitudes,
+where linear interpolation of lat and lon bends the edges. Slerp costs
+some trigonometric functions per new point, several times the cost of
+Resample.
+*/
+func (gpx *GPX) ResampleGreatCircle(spacingMeters float64) []Trkpt {
+	return gpx.resample(spacingMeters, slerp)
+}
+
+// resample returns the first track segment resampled as in Resample with
+// the new points interpolated by interp.
+func (gpx *GPX) resample(spacingMeters float64, interp func(p, q Trkpt, f float64) Trkpt) []Trkpt {
+	s := gpx.firstTrkpts()
+	if spacingMeters <= 0 || len(s) == 0 {
+		return nil
+	}
+	out := make([]Trkpt, 0, int(gpx.Distance()/spacingMeters)+2)
+	out = append(out, s[0])
+	cum, next, last := 0.0, spacingMeters, 0.0
+	for i := 1; i < len(s); i++ {
+		d := dist(s[i-1], s[i])
+		for next <= cum+d {
+			out = append(out, interp(s[i-1], s[i], (next-cum)/d))
+			last = next
+			next += spacingMeters
+		}
+		cum += d
+	}
+	if cum > last {
+		out = append(out, s[len(s)-1])
+	}
+	return out
+}
+
+// PointAtDistance returns the track point at meters along the first track
+// segment, interpolated linearly between the original track points. At
+// the distance of a track point, e.g. 0 or Distance(), the track point
+// itself is returned. The bool is false if meters is negative or exceeds
+// the track distance.
+func (gpx *GPX) PointAtDistance(meters float64) (Trkpt, bool) {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 || meters < 0 {
+		return Trkpt{}, false
+	}
+	if meters == 0 {
+		return s[0], true
+	}
+	cum := 0.0
+	for i := 1; i < len(s); i++ {
+		d := dist(s[i-1], s[i])
+		if cum+d > meters {
+			return interpolate(s[i-1], s[i], (meters-cum)/d), true
+		}
+		cum += d
+		if cum == meters { //exact hit on a track point, e.g. the last one
+			return s[i], true
+		}
+	}
+	return Trkpt{}, false
+}
+
+/*
+Densify inserts interpolated track points to the first track segment in
+place wherever consecutive points are over maxSpacingMeters apart, so
+that no spacing is over it, and returns the count of inserted points.
+The new points are evenly spaced between the two points as in Resample,
+the original points are kept. If maxSpacingMeters <= 0, nothing is done.
+*/
+func (gpx *GPX) Densify(maxSpacingMeters float64) int {
+	s := gpx.firstTrkpts()
+	if maxSpacingMeters <= 0 || len(s) < 2 {
+		return 0
+	}
+	out := make([]Trkpt, 0, len(s))
+	out = append(out, s[0])
+	for i := 1; i < len(s); i++ {
+		k := int(math.Ceil(dist(s[i-1], s[i])/maxSpacingMeters)) - 1 //points to insert
+		for j := 1; j <= k; j++ {
+			out = append(out, interpolate(s[i-1], s[i], float64(j)/float64(k+1)))
+		}
+		out = append(out, s[i])
+	}
+	if len(out) > len(s) {
+		gpx.Trks[0].Trksegs[0].Trkpts = out
+	}
+	return len(out) - len(s)
+}
@@ -0,0 +1,34 @@
+package gpx
+
+import "testing"
+
+func TestPointAtDistance(t *testing.T) {
+	gpx := parseTestdata(t, "dop.gpx")
+	s, cum := gpx.TrkpSlice(), gpx.CumulativeDistances()
+	total := gpx.Distance()
+	tests := []struct {
+		meters float64
+		idx    int // index of the expected track point, -1 interpolated
+		ok     bool
+	}{
+		{0, 0, true},
+		{cum[1], 1, true},
+		{cum[2], 2, true},
+		{total, len(s) - 1, true},
+		{(cum[1] + cum[2]) / 2, -1, true},
+		{-1, 0, false},
+		{total + 1, 0, false},
+	}
+	for _, tt := range tests {
+		p, ok := gpx.PointAtDistance(tt.meters)
+		switch {
+		case ok != tt.ok:
+			t.Errorf("%g m: ok = %v, want %v", tt.meters, ok, tt.ok)
+		case !ok:
+		case tt.idx >= 0 && (!sameTrkpt(p, s[tt.idx]) || p.Extra != s[tt.idx].Extra):
+			t.Errorf("%g m: got %v %v, want point %d %v %v", tt.meters, p, p.Extra, tt.idx, s[tt.idx], s[tt.idx].Extra)
+		case tt.idx < 0 && !(p.Lat > s[1].Lat && p.Lat < s[2].Lat):
+			t.Errorf("%g m: got %v, want between points 1 and 2", tt.meters, p)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"time"
+)
+
+// ErrNotLossless is returned by MarshalLossless, if the track points of
+// gpx do not match the original GPX data.
+var ErrNotLossless = errors.New("track points do not match the original data")
+
+/*
+MarshalLossless returns the original GPX data, from which gpx was parsed
+with the TrackOffsets option, with only the changed track point values
+substituted, e.g. to edit a GPX file under version control without
+reformatting it. It is a different strategy from Marshal, which writes
+all data anew: here all bytes outside the changed lat and lon attributes
+and <ele> and <time> elements are kept as they are, including attribute
+order, whitespace, comments and extensions. Changes of lat, lon, ele and
+time of the track points of the first track segment are written, a NaN
+ele and a zero time remove their elements. Other changes, e.g. of
+Extra, the header or the track name, are not written. Numbers are
+formatted by DefaultWriteOptions. The original track points are parsed
+with the ParseOptions of gpx, so that e.g. DecimalComma data compares
+equal, and elevations are written in the ElevationUnit of the options.
+
+The caller must keep the original data, and the track points must be
+the parsed ones, changed only in place: ErrNotLossless is returned, if
+gpx has no Offsets, it was parsed with custom Tags or track points have
+been added or removed. A changed track point with a NaN or infinite lat
+or lon is an ErrInvalidCoordinate error. The original data must have
+the standard <trkpt> tags and number format.
+*/
+func (gpx *GPX) MarshalLossless(original []byte) ([]byte, error) {
+	s := gpx.firstTrkpts()
+	if gpx.offsets == nil || gpx.offsOpts == nil || len(gpx.offsets) != len(s) ||
+		gpx.offsOpts.Tags != (Tags{}) {
+		return nil, ErrNotLossless
+	}
+	p := &parser{opts: *gpx.offsOpts, data: original}
+	p.setTags()
+	b := make([]byte, 0, len(original)+len(original)/16)
+	last := 0
+	for i, off := range gpx.offsets {
+		end := trkptEnd(original, off)
+		if off < last || end < 0 {
+			return nil, ErrNotLossless
+		}
+		elem := original[off:end]
+		orig, e := p.parseTrkpt(trkptBody(elem))
+		if e != nil {
+			return nil, errf("%w: trackpoint %d: %w", ErrNotLossless, i+1, e)
+		}
+		if sameValues(orig, s[i]) {
+			continue
+		}
+		if !hasCoords(s[i]) {
+			return nil, errf("trackpoint %d: %w", i+1, ErrInvalidCoordinate)
+		}
+		b = append(b, original[last:off]...)
+		b = append(b, editTrkpt(elem, orig, s[i], p.opts.ElevationUnit)...)
+		last = end
+	}
+	return append(b, original[last:]...), nil
+}
+
+// trkptEnd returns the index after the end of the <trkpt> element at
+// off in b, -1 if there is no track point element at off.
+func trkptEnd(b []byte, off int) int {
+	if off < 0 || !bytes.HasPrefix(b[min(off, len(b)):], starttag) {
+		return -1
+	}
+	g := bytes.IndexByte(b[off:], '>')
+	if g < 0 {
+		return -1
+	}
+	if b[off+g-1] == '/' { //self-closing <trkpt/>
+		return off + g + 1
+	}
+	r := bytes.Index(b[off:], closetag)
+	if r < 0 {
+		return -1
+	}
+	return off + r + len(closetag)
+}
+
+// trkptBody returns track point element elem without <trkpt and the
+// closing tag, as the fast parser parses it.
+func trkptBody(elem []byte) []byte {
+	if bytes.HasSuffix(elem, []byte("/>")) {
+		return elem[len(starttag) : len(elem)-2]
+	}
+	return elem[len(starttag) : len(elem)-len(closetag)]
+}
+
+// sameValues reports whether p and q have the same lat, lon, ele and time,
+// NaN values included, e.g. of PartialCoordinates.
+func sameValues(p, q Trkpt) bool {
+	same := func(a, b float64) bool { return a == b || math.IsNaN(a) && math.IsNaN(b) }
+	return same(p.Lat, q.Lat) && same(p.Lon, q.Lon) && same(p.Ele, q.Ele) && p.Time.Equal(q.Time)
+}
+
+// editTrkpt returns a copy of track point element elem parsed as orig
+// with the values of p, which differ from orig, substituted. Elevation is
+// written in unit, zero is meters.
+func editTrkpt(elem []byte, orig, p Trkpt, unit DistanceUnit) []byte {
+	opts := DefaultWriteOptions
+	e := bytes.Clone(elem)
+	if bytes.HasSuffix(e, []byte("/>")) { //room for elements
+		e = append(append(e[:len(e)-2], '>'), closetag...)
+	}
+	if p.Lat != orig.Lat {
+		e = setAttr(e, "lat", appendFloat(nil, p.Lat, opts.CoordDecimals))
+	}
+	if p.Lon != orig.Lon {
+		e = setAttr(e, "lon", appendFloat(nil, p.Lon, opts.CoordDecimals))
+	}
+	switch {
+	case p.Ele == orig.Ele || !p.HasEle() && !orig.HasEle():
+	case !p.HasEle():
+		e = removeElement(e, "ele")
+	default:
+		ele := p.Ele
+		if unit != 0 {
+			ele = unit.FromMeters(ele)
+		}
+		e = setElement(e, "ele", "", appendFloat(nil, ele, opts.EleDecimals))
+	}
+	switch {
+	case p.Time.Equal(orig.Time):
+	case p.Time.IsZero():
+		e = removeElement(e, "time")
+	default:
+		e = setElement(e, "time", "ele", p.Time.UTC().AppendFormat(nil, time.RFC3339Nano))
+	}
+	return e
+}
+
+// setAttr returns element e with the value of attribute name set to v.
+// If e has no such attribute, it is added to the start tag.
+func setAttr(e []byte, name string, v []byte) []byte {
+	l, r := attrSpan(e, name)
+	if l < 0 {
+		l = len(starttag)
+		v = append([]byte(" "+name+`="`), append(v, '"')...)
+		r = l
+	}
+	return replaceSpan(e, l, r, v)
+}
+
+// setElement returns element e with the text of its child element name
+// set to v. If e has no such child, it is added after child after, or
+// first, if there is no child after either.
+func setElement(e []byte, name, after string, v []byte) []byte {
+	if l := indexElement(e, name); l >= 0 {
+		g := bytes.IndexByte(e[l:], '>') + l
+		if e[g-1] == '/' { //empty <name/>
+			return replaceSpan(e, l, g+1, elementBytes(name, v))
+		}
+		if r := bytes.Index(e[g:], []byte("</"+name+">")); r >= 0 {
+			return replaceSpan(e, g+1, g+r, v)
+		}
+	}
+	at := bytes.IndexByte(e, '>') + 1
+	if after != "" {
+		if r := bytes.Index(e, []byte("</"+after+">")); r >= 0 {
+			at = r + len(after) + 3
+		}
+	}
+	return replaceSpan(e, at, at, elementBytes(name, v))
+}
+
+// removeElement returns element e without its child element name.
+func removeElement(e []byte, name string) []byte {
+	l := indexElement(e, name)
+	if l < 0 {
+		return e
+	}
+	g := bytes.IndexByte(e[l:], '>') + l
+	if e[g-1] == '/' {
+		return replaceSpan(e, l, g+1, nil)
+	}
+	if r := bytes.Index(e[g:], []byte("</"+name+">")); r >= 0 {
+		return replaceSpan(e, l, g+r+len(name)+3, nil)
+	}
+	return e
+}
+
+// elementBytes returns element <name>v</name>.
+func elementBytes(name string, v []byte) []byte {
+	b := append([]byte("<"+name+">"), v...)
+	return append(b, "</"+name+">"...)
+}
+
+// replaceSpan returns e with e[l:r] replaced by v.
+func replaceSpan(e []byte, l, r int, v []byte) []byte {
+	return append(e[:l:l], append(v, e[r:]...)...)
+}
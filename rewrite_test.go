@@ -0,0 +1,64 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMarshalLossless(t *testing.T) {
+	tests := []struct {
+		file string
+		opts ParseOptions
+		err  error
+	}{
+		{"short.gpx", lenient, nil},
+		{"crlf.gpx", lenient, nil},
+		{"comma.gpx", ParseOptions{DecimalComma: true}, nil},
+		{"multitrack.gpx", ParseOptions{AllowMissingElevation: true, ElevationUnit: Feet}, nil},
+		{"selfclosing.gpx", lenient, nil},
+		{"short.gpx", ParseOptions{Tags: Tags{Ele: "<ele>"}}, ErrNotLossless},
+	}
+	for _, tt := range tests {
+		data := readTestdata(t, tt.file)
+		tt.opts.TrackOffsets = true
+		gpx, err := Parse(data, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		b, err := gpx.MarshalLossless(data)
+		if !errors.Is(err, tt.err) {
+			t.Fatalf("%s: err = %v, want %v", tt.file, err, tt.err)
+		}
+		if err != nil {
+			continue
+		}
+		if !bytes.Equal(b, data) {
+			t.Errorf("%s: unchanged track points rewritten:\n%s", tt.file, b)
+		}
+		s := gpx.TrkpSlice()
+		s[0].Ele, s[0].Lat = 1234.5, s[0].Lat+0.5
+		b, err = gpx.MarshalLossless(data)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		r, err := Parse(b, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: reparse: %v\n%s", tt.file, err, b)
+		}
+		rs := r.TrkpSlice()
+		if math.Abs(rs[0].Ele-1234.5) > 0.01 || rs[0].Lat != s[0].Lat {
+			t.Errorf("%s: changed point reparsed as %v, want %v", tt.file, rs[0], s[0])
+		}
+		for i := 1; i < len(s); i++ {
+			if !sameTrkpt(rs[i], s[i]) {
+				t.Errorf("%s: point %d = %v, want %v", tt.file, i, rs[i], s[i])
+			}
+		}
+	}
+	gpx := parseTestdata(t, "short.gpx")
+	if _, err := gpx.MarshalLossless(readTestdata(t, "short.gpx")); !errors.Is(err, ErrNotLossless) {
+		t.Errorf("without TrackOffsets: err = %v, want %v", err, ErrNotLossless)
+	}
+}
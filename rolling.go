@@ -0,0 +1,102 @@
+package gpx
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"time"
+)
+
+// ErrUnknownField is returned for an unknown track point field name.
+var ErrUnknownField = errors.New("unknown track point field")
+
+/*
+RollingAverage returns the time-windowed moving average of track point
+field, "ele" or a TrkptExtra element name, e.g. "power" or "hr", for each
+track point of the first track segment. The average at point i is over
+the window time before it. Each value is weighted by the time from the
+previous point, so irregular sampling is accounted for, and the first
+point of the window is clipped to the window start. Points without time
+or value are skipped and their average is NaN. The average is the value
+itself, if the window has no earlier points.
+*/
+func (gpx *GPX) RollingAverage(field string, window time.Duration) ([]float64, error) {
+	v, e := gpx.series(field)
+	if e != nil {
+		return nil, e
+	}
+	s := gpx.firstTrkpts()
+	avg := make([]float64, len(s))
+	for i := range s {
+		avg[i] = math.NaN()
+		if s[i].Time.IsZero() || math.IsNaN(v[i]) {
+			continue
+		}
+		start := s[i].Time.Add(-window)
+		sum, w := 0.0, 0.0
+		for j := i; j > 0 && s[j].Time.After(start); j-- {
+			prev := s[j-1].Time
+			if prev.IsZero() || math.IsNaN(v[j]) {
+				continue
+			}
+			if prev.Before(start) {
+				prev = start
+			}
+			dt := s[j].Time.Sub(prev).Seconds()
+			if dt > 0 {
+				sum += v[j] * dt
+				w += dt
+			}
+		}
+		avg[i] = v[i]
+		if w > 0 {
+			avg[i] = sum / w
+		}
+	}
+	return avg, nil
+}
+
+/*
+NormalizedPower returns the normalized power of the first track segment
+in watts: the fourth root of the mean of the fourth powers of the 30
+second rolling average power. Points without rolling average are skipped.
+ErrNoTime is returned if there is no power data with time.
+*/
+func (gpx *GPX) NormalizedPower() (float64, error) {
+	avg, e := gpx.RollingAverage("power", 30*time.Second)
+	if e != nil {
+		return 0, e
+	}
+	sum, n := 0.0, 0
+	for _, p := range avg {
+		if !math.IsNaN(p) {
+			sum += p * p * p * p
+			n++
+		}
+	}
+	if n == 0 {
+		return 0, ErrNoTime
+	}
+	return math.Pow(sum/float64(n), 0.25), nil
+}
+
+// series returns the values of track point field of the first track
+// segment, NaN for missing values.
+func (gpx *GPX) series(field string) ([]float64, error) {
+	s := gpx.firstTrkpts()
+	if field != "ele" && !slices.Contains(extraNames, field) {
+		return nil, errf("%w: %q", ErrUnknownField, field)
+	}
+	v := make([]float64, len(s))
+	for i, p := range s {
+		switch {
+		case field == "ele":
+			v[i] = p.Ele
+		case p.Extra == nil:
+			v[i] = math.NaN()
+		default:
+			v[i] = *p.Extra.field(field)
+		}
+	}
+	return v, nil
+}
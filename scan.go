@@ -0,0 +1,127 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+)
+
+/*
+ScanTrackpoints calls fn for each track point of GPX data with the raw
+track point slice found by the fast parser, e.g.
+
+	lon="-5.760211" lat="37.942557"> <ele>615.25</ele>
+
+without the <trkpt start and </trkpt> closing tags. The slice aliases
+gpxbytes: it is not copied and fn must not modify it. A retained slice
+keeps the whole gpxbytes in memory, use ScanTrackpointsCopy to retain
+the slices. Scanning stops at the first error of fn, which is returned.
+ErrNoTrackpoints is returned if there are no track points.
+*/
+func ScanTrackpoints(gpxbytes []byte, fn func(raw []byte) error) error {
+	var trkpSlice []byte
+
+	p := &parser{data: gpxbytes}
+	p.setTags()
+	b, e := p.selectTrkSegment(trimBOM(gpxbytes))
+	if e != nil {
+		return e
+	}
+	p.init(b)
+	for {
+		trkpSlice, b = p.nextTrkpt(b)
+		if trkpSlice == nil {
+			return nil
+		}
+		if e := fn(trkpSlice); e != nil {
+			return e
+		}
+	}
+}
+
+// ScanTrackpointsCopy is like ScanTrackpoints, but fn is called with a
+// copy of the raw track point slice, which fn may modify and retain.
+func ScanTrackpointsCopy(gpxbytes []byte, fn func(raw []byte) error) error {
+	return ScanTrackpoints(gpxbytes, func(raw []byte) error {
+		return fn(bytes.Clone(raw))
+	})
+}
+
+// errNoScanner is returned by Next of a Scanner not made by NewScanner.
+var errNoScanner = errors.New("scanner not created by NewScanner")
+
+/*
+Scanner parses the track points of GPX data one at a time, e.g. to stop
+early or to interleave parsing with other work. Track points are found
+and parsed as in ParseGPX, all track segments merged. A Scanner must be
+created by NewScanner or NewScannerWithOptions, the zero value is not
+usable. A Scanner is not safe for concurrent use.
+*/
+type Scanner struct {
+	p      *parser
+	rest   []byte //data after the scanned track points
+	n      int    //number of valid scanned track points
+	errcnt int    //count of ignored track point errors
+	err    error  //setup error, returned by the first Next
+	done   bool
+}
+
+// NewScanner returns a Scanner of gpxbytes with the options of ParseGPX
+// without ignoring errors.
+func NewScanner(gpxbytes []byte) *Scanner {
+	return NewScannerWithOptions(gpxbytes, ParseOptions{})
+}
+
+// NewScannerWithOptions is like NewScanner, but the track points are
+// parsed by opts. opts.UseXMLParser, TrackOffsets and StrictXML are ignored.
+func NewScannerWithOptions(gpxbytes []byte, opts ParseOptions) *Scanner {
+	s := &Scanner{p: &parser{opts: opts, data: gpxbytes}}
+	if s.err = s.p.setTags(); s.err != nil {
+		return s
+	}
+	if opts.tooLarge(int64(len(gpxbytes))) {
+		s.err = ErrTooLarge
+		return s
+	}
+	s.rest, s.err = s.p.selectTrkSegment(trimBOM(gpxbytes))
+	if s.err == nil {
+		s.p.init(s.rest)
+	}
+	return s
+}
+
+/*
+Next returns the next track point. The bool is false at the end of the
+track points or after an error, which ends scanning, e.g. ErrNoTrackpoints
+for data without track points. An error with the bool true is an error
+of a single track point: scanning can be continued by calling Next
+again. With opts.IgnoreErrors such track points are skipped and counted
+by ErrorCount.
+*/
+func (s *Scanner) Next() (Trkpt, bool, error) {
+	switch {
+	case s.p == nil:
+		return Trkpt{}, false, errNoScanner
+	case s.err != nil:
+		s.done = true
+		e := s.err
+		s.err = nil
+		return Trkpt{}, false, e
+	case s.done:
+		return Trkpt{}, false, nil
+	}
+	trkp, trkpSlice, e := s.p.next(&s.rest, &s.errcnt)
+	if trkpSlice == nil {
+		s.done = true
+		return Trkpt{}, false, e
+	}
+	if e != nil {
+		return Trkpt{}, true, errf("trackpoint %d: %w", s.n+1, e)
+	}
+	s.n++
+	return trkp, true, nil
+}
+
+// ErrorCount returns the count of track point errors ignored so far.
+func (s *Scanner) ErrorCount() int {
+	return s.errcnt
+}
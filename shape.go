@@ -0,0 +1,73 @@
+package gpx
+
+import (
+	"math"
+	"sort"
+)
+
+/*
+ConvexHull returns the vertices of the convex hull of the track points of
+the first track segment in counter-clockwise order, the first vertex not
+repeated at the end. Lon and lat are treated as planar x and y, which is
+fine for small extents not crossing the antimeridian. Andrew's monotone
+chain algorithm is used. For less than 3 points a copy of the points is
+returned, for collinear points the two end points and for equal points
+a single point.
+*/
+func (gpx *GPX) ConvexHull() []Trkpt {
+	p := append([]Trkpt{}, gpx.firstTrkpts()...)
+	if len(p) < 3 {
+		return p
+	}
+	sort.Slice(p, func(i, j int) bool {
+		return p[i].Lon < p[j].Lon || (p[i].Lon == p[j].Lon && p[i].Lat < p[j].Lat)
+	})
+	hull := make([]Trkpt, 0, 2*len(p))
+	for _, q := range p { //lower hull
+		for len(hull) >= 2 && cross(hull[len(hull)-2], hull[len(hull)-1], q) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, q)
+	}
+	lower := len(hull) + 1
+	for i := len(p) - 2; i >= 0; i-- { //upper hull
+		q := p[i]
+		for len(hull) >= lower && cross(hull[len(hull)-2], hull[len(hull)-1], q) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, q)
+	}
+	hull = hull[:len(hull)-1] //last is the first
+	if len(hull) == 2 && hull[0].Lat == hull[1].Lat && hull[0].Lon == hull[1].Lon {
+		return hull[:1] //all points are equal
+	}
+	return hull
+}
+
+// cross returns the z component of the cross product of vectors
+// o->a and o->b in the lon-lat plane, > 0 for a counter-clockwise turn.
+func cross(o, a, b Trkpt) float64 {
+	return (a.Lon-o.Lon)*(b.Lat-o.Lat) - (a.Lat-o.Lat)*(b.Lon-o.Lon)
+}
+
+/*
+Area returns the area in square meters enclosed by the first track
+segment as a closed polygon on a sphere of EarthRadius. The polygon is
+closed implicitly from the last to the first track point. Area is
+meaningful only for a loop track, which does not cross itself.
+*/
+func (gpx *GPX) Area() float64 {
+	const rad = math.Pi / 180
+	s := gpx.firstTrkpts()
+	if len(s) < 3 {
+		return 0
+	}
+	a := 0.0
+	q := s[len(s)-1]
+	for _, p := range s {
+		dlon := lonDelta(q.Lon, p.Lon) * rad
+		a += dlon * (2 + math.Sin(q.Lat*rad) + math.Sin(p.Lat*rad))
+		q = p
+	}
+	return math.Abs(a) * EarthRadius * EarthRadius / 2
+}
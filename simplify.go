@@ -0,0 +1,86 @@
+package gpx
+
+/*
+Simplify removes track points of the first track segment in place by the
+Douglas-Peucker algorithm and returns the count of removed points. The
+kept points are the ones, which differ over epsilonMeters from the
+simplified line. Distances are computed in a local plane as in
+SnapToTrack. The first and the last points are kept.
+*/
+func (gpx *GPX) Simplify(epsilonMeters float64) int {
+	s := gpx.firstTrkpts()
+	if len(s) < 3 {
+		return 0
+	}
+	keep := douglasPeucker(s, epsilonMeters)
+	n := 0
+	for i, p := range s {
+		if keep[i] {
+			s[n] = p
+			n++
+		}
+	}
+	clear(s[n:])
+	gpx.Trks[0].Trksegs[0].Trkpts = s[:n]
+	return len(s) - n
+}
+
+/*
+SimplifyToCount simplifies the first track segment as in Simplify to at
+most maxPoints points, at least 2, and returns the count of kept points.
+The smallest epsilon giving at most maxPoints points is binary searched,
+so SimplifyToCount runs Douglas-Peucker some tens of times and is slower
+than Simplify with a known epsilon.
+*/
+func (gpx *GPX) SimplifyToCount(maxPoints int) int {
+	s := gpx.firstTrkpts()
+	maxPoints = max(maxPoints, 2)
+	if len(s) <= maxPoints {
+		return len(s)
+	}
+	count := func(epsilon float64) int {
+		n := 0
+		for _, k := range douglasPeucker(s, epsilon) {
+			if k {
+				n++
+			}
+		}
+		return n
+	}
+	lo, hi := 0.0, gpx.Distance() //no point is farther from the line
+	for i := 0; i < 50 && hi-lo > 1e-3; i++ {
+		mid := (lo + hi) / 2
+		if count(mid) <= maxPoints {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	gpx.Simplify(hi)
+	return len(gpx.firstTrkpts())
+}
+
+// douglasPeucker returns the points of s kept by the Douglas-Peucker
+// algorithm with tolerance epsilon meters.
+func douglasPeucker(s []Trkpt, epsilon float64) []bool {
+	keep := make([]bool, len(s))
+	keep[0], keep[len(s)-1] = true, true
+	stack := [][2]int{{0, len(s) - 1}}
+	for len(stack) > 0 {
+		i, j := stack[len(stack)-1][0], stack[len(stack)-1][1]
+		stack = stack[:len(stack)-1]
+		far, farD := -1, epsilon
+		for k := i + 1; k < j; k++ {
+			ax, ay := localXY(s[k].Lat, s[k].Lon, s[i])
+			bx, by := localXY(s[k].Lat, s[k].Lon, s[j])
+			if _, d := projectOrigin(ax, ay, bx, by); d > farD {
+				far, farD = k, d
+			}
+		}
+		if far >= 0 {
+			keep[far] = true
+			stack = append(stack, [2]int{i, far}, [2]int{far, j})
+		}
+	}
+	return keep
+}
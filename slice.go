@@ -0,0 +1,77 @@
+package gpx
+
+import "sort"
+
+/*
+Slice returns a new GPX with the track points startIdx up to but not
+including endIdx of the first track segment, as in s[startIdx:endIdx].
+The indices are clamped to the segment. The GPX has the header data and
+the first track name, comment and description of gpx and the track
+points are copies.
+*/
+func (gpx *GPX) Slice(startIdx, endIdx int) *GPX {
+	s := gpx.firstTrkpts()
+	startIdx = min(max(startIdx, 0), len(s))
+	endIdx = min(max(endIdx, startIdx), len(s))
+	return gpx.sub(s[startIdx:endIdx])
+}
+
+/*
+SliceByDistance is like Slice, but the track points are from startM to
+endM meters of cumulative distance along the first track segment. The
+end points are interpolated as in PointAtDistance. The distances are
+clamped to the track length and if startM > endM, the track is empty.
+*/
+func (gpx *GPX) SliceByDistance(startM, endM float64) *GPX {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return gpx.sub(nil)
+	}
+	cum := cumDistances(s)
+	total := cum[len(s)-1]
+	startM = min(max(startM, 0), total)
+	endM = min(max(endM, 0), total)
+	if startM > endM {
+		return gpx.sub(nil)
+	}
+	p, i := atDistance(s, cum, startM)
+	if startM == endM {
+		return gpx.sub([]Trkpt{p})
+	}
+	q, j := atDistance(s, cum, endM)
+	if cum[i] == startM {
+		i++ //p is s[i]
+	}
+	pts := append([]Trkpt{p}, s[i:j]...)
+	return gpx.sub(append(pts, q))
+}
+
+// atDistance returns the track point at meters of cumulative distance cum
+// of s and the index of the first track point at or after it.
+func atDistance(s []Trkpt, cum []float64, meters float64) (Trkpt, int) {
+	i := sort.SearchFloat64s(cum, meters)
+	if i == len(s) {
+		return s[len(s)-1], len(s) - 1
+	}
+	if cum[i] == meters || i == 0 {
+		return s[i], i
+	}
+	return interpolate(s[i-1], s[i], (meters-cum[i-1])/(cum[i]-cum[i-1])), i
+}
+
+// sub returns a new GPX with the header data and the first track name,
+// comment and description of gpx and a single track segment with copies of track points s.
+func (gpx *GPX) sub(s []Trkpt) *GPX {
+	c := &GPX{
+		Creator:  gpx.Creator,
+		Version:  gpx.Version,
+		Time:     gpx.Time,
+		Metadata: gpx.Metadata,
+	}
+	c.Trks = []Trk{{Trksegs: []Trkseg{{Trkpts: copyTrkpts(s)}}}}
+	if len(gpx.Trks) > 0 {
+		t := gpx.Trks[0]
+		c.Trks[0].Name, c.Trks[0].Cmt, c.Trks[0].Desc = t.Name, t.Cmt, t.Desc
+	}
+	return c
+}
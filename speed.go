@@ -0,0 +1,43 @@
+package gpx
+
+import "time"
+
+/*
+SmoothedSpeeds returns the speed in m/s at each track point of the first
+track segment over a sliding time window centered on the point: the
+distance along the track between the first and the last point in the
+window divided by their time difference. Irregular sampling is handled,
+as the actual time difference is used. If the window has no time span,
+e.g. shorter than the sample interval, the neighbor points are used.
+The times should be increasing, see FixTimeMonotonic. ErrNoTime is
+returned if a track point has no time.
+*/
+func (gpx *GPX) SmoothedSpeeds(window time.Duration) ([]float64, error) {
+	s := gpx.firstTrkpts()
+	for _, p := range s {
+		if p.Time.IsZero() {
+			return nil, ErrNoTime
+		}
+	}
+	cum := cumDistances(s)
+	speeds := make([]float64, len(s))
+	lo, hi := 0, 0
+	for i, p := range s {
+		from, to := p.Time.Add(-window/2), p.Time.Add(window/2)
+		for lo < i && s[lo].Time.Before(from) {
+			lo++
+		}
+		hi = max(hi, i)
+		for hi+1 < len(s) && !s[hi+1].Time.After(to) {
+			hi++
+		}
+		l, r := lo, hi
+		if !s[r].Time.After(s[l].Time) {
+			l, r = max(i-1, 0), min(i+1, len(s)-1)
+		}
+		if dt := s[r].Time.Sub(s[l].Time).Seconds(); dt > 0 {
+			speeds[i] = (cum[r] - cum[l]) / dt
+		}
+	}
+	return speeds, nil
+}
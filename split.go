@@ -0,0 +1,149 @@
+package gpx
+
+import "time"
+
+/*
+SplitByDistance splits the first track segment to chunks, each spanning
+at least meters of cumulative haversine distance, except the final
+remainder chunk, which is shorter. The end point of a chunk is the start
+point of the next chunk, so the chunks are continuous. The chunks share
+the track point array of the segment and they have no excess capacity.
+If meters <= 0 or the track is empty, nil is returned.
+*/
+func (gpx *GPX) SplitByDistance(meters float64) [][]Trkpt {
+	var chunks [][]Trkpt
+
+	s := gpx.firstTrkpts()
+	if meters <= 0 || len(s) == 0 {
+		return nil
+	}
+	if len(s) == 1 {
+		return [][]Trkpt{s[:1:1]}
+	}
+	i0, d := 0, 0.0
+	for i := 1; i < len(s); i++ {
+		d += dist(s[i-1], s[i])
+		if d >= meters {
+			chunks = append(chunks, s[i0:i+1:i+1])
+			i0, d = i, 0
+		}
+	}
+	if i0 < len(s)-1 {
+		chunks = append(chunks, s[i0:len(s):len(s)])
+	}
+	return chunks
+}
+
+// SplitByDistanceCopy is like SplitByDistance, but the chunks are copies
+// of the track points with copies of their Extra. They share nothing with
+// gpx or each other.
+func (gpx *GPX) SplitByDistanceCopy(meters float64) [][]Trkpt {
+	chunks := gpx.SplitByDistance(meters)
+	for i, c := range chunks {
+		chunks[i] = copyTrkpts(c)
+	}
+	return chunks
+}
+
+/*
+SplitByTimeGap splits the first track segment to several track segments of
+the first track wherever the time between consecutive track points exceeds
+maxGap, e.g. at auto-pauses. The new segments replace the first segment and
+share its track point array. Track points without time never start a new
+segment. Returns the number of segments the first segment was split to.
+*/
+func (gpx *GPX) SplitByTimeGap(maxGap time.Duration) int {
+	var segs []Trkseg
+
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return 0
+	}
+	i0 := 0
+	for i := 1; i < len(s); i++ {
+		t0, t1 := s[i-1].Time, s[i].Time
+		if !t0.IsZero() && !t1.IsZero() && t1.Sub(t0) > maxGap {
+			segs = append(segs, Trkseg{Trkpts: s[i0:i:i]})
+			i0 = i
+		}
+	}
+	if i0 == 0 {
+		return 1
+	}
+	segs = append(segs, Trkseg{Trkpts: s[i0:len(s):len(s)]})
+	trk := &gpx.Trks[0]
+	trk.Trksegs = append(segs, trk.Trksegs[1:]...)
+	return len(segs)
+}
+
+/*
+MergeSegments folds each track segment with less than minPoints track
+points into the previous segment of its track, e.g. to undo fragments
+of SplitByTimeGap. A short first segment is folded into the next one.
+The order of the track points is kept. A track with only short segments
+becomes a single segment.
+*/
+func (gpx *GPX) MergeSegments(minPoints int) {
+	for i := range gpx.Trks {
+		trk := &gpx.Trks[i]
+		var segs []Trkseg
+		for _, seg := range trk.Trksegs {
+			switch {
+			case len(segs) == 0:
+				segs = append(segs, seg)
+			case len(seg.Trkpts) < minPoints:
+				last := &segs[len(segs)-1]
+				last.Trkpts = append(clip(last.Trkpts), seg.Trkpts...)
+			case len(segs) == 1 && len(segs[0].Trkpts) < minPoints:
+				segs[0].Trkpts = append(clip(segs[0].Trkpts), seg.Trkpts...)
+			default:
+				segs = append(segs, seg)
+			}
+		}
+		trk.Trksegs = segs
+	}
+}
+
+// clip returns s without excess capacity, so that an append to it does
+// not overwrite a following segment sharing the same array.
+func clip(s []Trkpt) []Trkpt {
+	return s[:len(s):len(s)]
+}
+
+/*
+SplitEqual splits the first track segment to k legs of equal cumulative
+distance. The boundary points are interpolated as in PointAtDistance and
+the end point of a leg is the start point of the next leg, so the legs
+are continuous. The legs are new slices. If k is greater than the number
+of track points, legs can have only the two interpolated points. If k <= 1
+or the track has no length, a single leg with all track points is
+returned. An empty track gives nil.
+*/
+func (gpx *GPX) SplitEqual(k int) [][]Trkpt {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 {
+		return nil
+	}
+	cum := cumDistances(s)
+	total := cum[len(cum)-1]
+	if k <= 1 || total == 0 {
+		return [][]Trkpt{append([]Trkpt(nil), s...)}
+	}
+	legs := make([][]Trkpt, k)
+	i := 1 //first point after the current leg start
+	start := s[0]
+	for j := range legs {
+		end := s[len(s)-1]
+		m := total * float64(j+1) / float64(k)
+		leg := []Trkpt{start}
+		for ; i < len(s) && cum[i] < m; i++ {
+			leg = append(leg, s[i])
+		}
+		if j < k-1 && i < len(s) {
+			end = interpolate(s[i-1], s[i], (m-cum[i-1])/(cum[i]-cum[i-1]))
+		}
+		legs[j] = append(leg, end)
+		start = end
+	}
+	return legs
+}
@@ -0,0 +1,84 @@
+package gpx
+
+import (
+	"math"
+	"time"
+)
+
+// Stats is a summary of a track segment. Elevation values are NaN, if
+// no track point has elevation. Time values are zero, if HasTime is false.
+type Stats struct {
+	Points        int
+	Distance      float64 // haversine distance in meters
+	ElevationGain float64 // with DefaultGainThreshold, meters
+	ElevationLoss float64
+	MinEle        float64
+	MaxEle        float64
+	Bounds        Bounds
+	HasTime       bool          // the first and the last track point have time
+	Duration      time.Duration // from the first to the last track point
+	AvgSpeed      float64       // Distance / Duration, m/s
+	MaxSpeed      float64       // maximum speed between consecutive track points, m/s
+}
+
+/*
+Stats returns the summary statistics of the first track segment computed
+in a single pass over the track points. Track points without time are
+skipped in MaxSpeed.
+*/
+func (gpx *GPX) Stats() Stats {
+	return statsOf(gpx.firstTrkpts())
+}
+
+// SegmentStats returns the Stats of each track segment of all tracks in
+// order, e.g. of laps. An empty segment has zero Points and Distance.
+func (gpx *GPX) SegmentStats() []Stats {
+	var stats []Stats
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			stats = append(stats, statsOf(seg.Trkpts))
+		}
+	}
+	return stats
+}
+
+// statsOf returns the Stats of track points s.
+func statsOf(s []Trkpt) Stats {
+	st := Stats{Points: len(s), MinEle: math.NaN(), MaxEle: math.NaN()}
+	st.Bounds, _ = boundsOf(Bounds{}, false, s)
+	c := newClimb(DefaultGainThreshold)
+
+	for i, p := range s {
+		c.add(p.Ele)
+		if !math.IsNaN(p.Ele) {
+			if math.IsNaN(st.MinEle) {
+				st.MinEle, st.MaxEle = p.Ele, p.Ele
+			}
+			st.MinEle = min(st.MinEle, p.Ele)
+			st.MaxEle = max(st.MaxEle, p.Ele)
+		}
+		if i == 0 {
+			continue
+		}
+		q := s[i-1]
+		d := dist(q, p)
+		st.Distance += d
+		if q.Time.IsZero() || p.Time.IsZero() {
+			continue
+		}
+		if dt := p.Time.Sub(q.Time).Seconds(); dt > 0 {
+			st.MaxSpeed = max(st.MaxSpeed, d/dt)
+		}
+	}
+	st.ElevationGain, st.ElevationLoss = c.gain, c.loss
+	if len(s) > 0 && !s[0].Time.IsZero() && !s[len(s)-1].Time.IsZero() {
+		d := s[len(s)-1].Time.Sub(s[0].Time) //as in Duration
+		st.HasTime, st.Duration = true, d
+		if d > 0 {
+			st.AvgSpeed = st.Distance / d.Seconds()
+		}
+	} else {
+		st.MaxSpeed = 0
+	}
+	return st
+}
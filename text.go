@@ -0,0 +1,296 @@
+package gpx
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// trackText returns the <name>, <cmt> and <desc> of the first <trk> in
+// head, which is the GPX data before the first track point. Missing ones
+// are "".
+func trackText(head []byte) (name, cmt, desc string) {
+	l := indexElement(head, "trk")
+	if l < 0 {
+		return "", "", ""
+	}
+	name, _ = elementText(head[l:], "name")
+	cmt, _ = elementText(head[l:], "cmt")
+	desc, _ = elementText(head[l:], "desc")
+	return name, cmt, desc
+}
+
+/*
+gpxRoot returns b from the <gpx> root element on, if b has one. Content
+before it, e.g. a SOAP envelope or an HTML page wrapping the GPX data,
+is dropped, so that its elements are not taken as GPX header elements.
+*/
+func gpxRoot(b []byte) []byte {
+	if l := indexElement(b, "gpx"); l > 0 {
+		return b[l:]
+	}
+	return b
+}
+
+// xmlDecl returns the XML declaration <?xml ... ?> at the beginning of b,
+// nil if there is none.
+func xmlDecl(b []byte) []byte {
+	if !bytes.HasPrefix(b, []byte("<?xml")) {
+		return nil
+	}
+	if r := bytes.Index(b, []byte("?>")); r >= 0 {
+		return b[:r+2]
+	}
+	return nil
+}
+
+/*
+parseHeader sets Version, Creator, Time and Metadata of gpx from head,
+which is the GPX data before the first track point. In GPX 1.1 time and
+metadata are in the <metadata> element and in GPX 1.0 they are directly
+under <gpx>, where <url> and <urlname> are the Link.
+*/
+func (gpx *GPX) parseHeader(head []byte) {
+	if l := indexElement(head, "gpx"); l >= 0 {
+		gpx.Version = attrText(head[l:], "version")
+		gpx.Creator = attrText(head[l:], "creator")
+	}
+	if gpx.Version == "1.0" {
+		gpx.Metadata, gpx.Time = metadata10(header(head))
+		return
+	}
+	gpx.Metadata, gpx.Time = metadata(head)
+}
+
+// header returns the GPX 1.0 header data of head, which is the data
+// before the first <wpt>, <rte> or <trk> element.
+func header(head []byte) []byte {
+	for _, tag := range []string{"wpt", "rte", "trk"} {
+		if l := indexElement(head, tag); l >= 0 {
+			head = head[:l]
+		}
+	}
+	return head
+}
+
+// metadata returns the Metadata and time of the GPX 1.1 <metadata>
+// element in head.
+func metadata(head []byte) (m Metadata, time string) {
+	l := indexElement(head, "metadata")
+	if l < 0 {
+		return m, ""
+	}
+	b := head[l:]
+	if r := bytes.Index(b, []byte("</metadata>")); r >= 0 {
+		b = b[:r]
+	}
+	if l := indexElement(b, "author"); l >= 0 {
+		m.Author, _ = elementText(b[l:], "name")
+	}
+	if l := indexElement(b, "link"); l >= 0 {
+		m.Link.Href = attrText(b[l:], "href")
+		m.Link.Text, _ = elementText(b[l:], "text")
+	}
+	m.Keywords, _ = elementText(b, "keywords")
+	time, _ = elementText(b, "time")
+	return m, time
+}
+
+// metadata10 returns the Metadata and time of GPX 1.0 header h.
+func metadata10(h []byte) (m Metadata, time string) {
+	m.Author, _ = elementText(h, "author")
+	m.Link.Href, _ = elementText(h, "url")
+	m.Link.Text, _ = elementText(h, "urlname")
+	m.Keywords, _ = elementText(h, "keywords")
+	time, _ = elementText(h, "time")
+	return m, time
+}
+
+// attrText returns the value of attribute name of the start tag at the
+// beginning of b with XML entities decoded, "" if there is no such attribute.
+func attrText(b []byte, name string) string {
+	l, r := attrSpan(b, name)
+	if l < 0 {
+		return ""
+	}
+	return decodeEntities(string(b[l:r]))
+}
+
+// attrSpan returns the start and end index in b of the raw value of
+// attribute name of the start tag at the beginning of b, -1, -1 if there
+// is no such attribute.
+func attrSpan(b []byte, name string) (l, r int) {
+	if g := bytes.IndexByte(b, '>'); g >= 0 {
+		b = b[:g]
+	}
+	for j := 0; ; {
+		d := bytes.Index(b[j:], []byte(name))
+		if d < 0 {
+			return -1, -1
+		}
+		j += d
+		k := j + len(name)
+		if j == 0 || b[j-1] > ' ' || k >= len(b) || b[k] != '=' || k+1 >= len(b) {
+			j = k
+			continue
+		}
+		q := b[k+1]
+		r := bytes.IndexByte(b[k+2:], q)
+		if (q != '"' && q != '\'') || r < 0 {
+			return -1, -1
+		}
+		return k + 2, k + 2 + r
+	}
+}
+
+// indexElement returns the index of the first start tag <tag> or
+// <tag attributes...> in b, or -1 if there is none. Tags with tag as
+// a prefix, e.g. <trkseg for trk, are skipped. Namespace prefixed tags,
+// e.g. <ns2:trk, are found too.
+func indexElement(b []byte, tag string) int {
+	j := 0
+	for {
+		d := bytes.Index(b[j:], []byte(tag))
+		if d < 0 {
+			return -1
+		}
+		j += d
+		k := j + len(tag)
+		if k < len(b) && (b[k] == '>' || b[k] == '/' || b[k] <= ' ') {
+			if l := tagStart(b, j); l >= 0 {
+				return l
+			}
+		}
+		j = k
+	}
+}
+
+// tagStart returns the index of the < of the start tag with local name
+// at b[j:], -1 if b[j:] is not a local name of a start tag.
+func tagStart(b []byte, j int) int {
+	if j == 0 {
+		return -1
+	}
+	if b[j-1] == '<' {
+		return j - 1
+	}
+	if b[j-1] != ':' {
+		return -1
+	}
+	for i := j - 2; i >= 0; i-- {
+		switch c := b[i]; {
+		case c == '<':
+			if i == j-2 || b[i+1] == '/' {
+				return -1
+			}
+			return i
+		case c <= ' ' || c == '>' || c == ':' || c == '"' || c == '\'':
+			return -1
+		}
+	}
+	return -1
+}
+
+// elementText returns the trimmed text of the first element <tag>text</tag>
+// in b with XML entities decoded. The bool is false if there is no such element.
+func elementText(b []byte, tag string) (string, bool) {
+	l := indexElement(b, tag)
+	if l < 0 {
+		return "", false
+	}
+	g := bytes.IndexByte(b[l:], '>')
+	if g < 0 {
+		return "", false
+	}
+	g += l
+	if b[g-1] == '/' { //empty <tag/>
+		return "", true
+	}
+	name := b[l+1 : bytes.Index(b[l:], []byte(tag))+l+len(tag)] //with namespace prefix
+	r := bytes.Index(b[g:], []byte("</"+string(name)+">")) + g
+	if r < g {
+		return "", false
+	}
+	return decodeEntities(string(trimSpace(b[g+1 : r]))), true
+}
+
+// decodeEntities replaces the predefined XML entities &amp; &lt; &gt;
+// &quot; &apos; and decimal and hexadecimal character references, e.g.
+// &#233; and &#xE9;, in s. Unknown entities are left as they are.
+func decodeEntities(s string) string {
+	i := strings.IndexByte(s, '&')
+	if i < 0 {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for ; i >= 0; i = strings.IndexByte(s, '&') {
+		b.WriteString(s[:i])
+		s = s[i:]
+		j := strings.IndexByte(s, ';')
+		if r, ok := entity(s, j); ok {
+			b.WriteString(r)
+			s = s[j+1:]
+			continue
+		}
+		b.WriteByte('&')
+		s = s[1:]
+	}
+	b.WriteString(s)
+	return b.String()
+}
+
+// entity returns the replacement of entity s[:j+1], e.g. &amp; or &#233;.
+func entity(s string, j int) (string, bool) {
+	const maxLen = 10
+	if j < 2 || j > maxLen {
+		return "", false
+	}
+	switch name := s[1:j]; name {
+	case "amp":
+		return "&", true
+	case "lt":
+		return "<", true
+	case "gt":
+		return ">", true
+	case "quot":
+		return `"`, true
+	case "apos":
+		return "'", true
+	}
+	base, digits := 10, s[2:j]
+	if s[1] != '#' {
+		return "", false
+	}
+	if len(digits) > 0 && (digits[0] == 'x' || digits[0] == 'X') {
+		base, digits = 16, digits[1:]
+	}
+	n, e := strconv.ParseUint(digits, base, 32)
+	if e != nil || !utf8.ValidRune(rune(n)) {
+		return "", false
+	}
+	return string(rune(n)), true
+}
+
+// appendEscaped appends s to b with the XML special characters & < > " '
+// replaced by the entities decodeEntities decodes.
+func appendEscaped(b []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; c {
+		case '&':
+			b = append(b, "&amp;"...)
+		case '<':
+			b = append(b, "&lt;"...)
+		case '>':
+			b = append(b, "&gt;"...)
+		case '"':
+			b = append(b, "&quot;"...)
+		case '\'':
+			b = append(b, "&apos;"...)
+		default:
+			b = append(b, c)
+		}
+	}
+	return b
+}
@@ -0,0 +1,60 @@
+package gpx
+
+import "math"
+
+// Tile is a Web Mercator (slippy map) XYZ tile at some zoom level.
+type Tile struct {
+	X, Y int
+}
+
+// maxMercatorLat is the latitude limit of Web Mercator tiles.
+const maxMercatorLat = 85.05112878
+
+// maxTileZoom is the largest zoom level of Tiles.
+const maxTileZoom = 30
+
+/*
+Tiles returns the unique tiles at zoom level zoom covered by the track
+points of the first track segment, in the order the track enters them.
+The standard Web Mercator tile math is used:
+
+	n = 2^zoom
+	x = floor((lon + 180) / 360 * n)
+	y = floor((1 - ln(tan(lat) + 1/cos(lat)) / π) / 2 * n)
+
+Latitudes are clamped to ±85.0511°. Only tiles having a track point are
+returned, a long edge between two points may cross tiles not returned.
+The zoom level must be 0..30, otherwise Tiles returns nil.
+*/
+func (gpx *GPX) Tiles(zoom int) []Tile {
+	var tiles []Tile
+
+	if zoom < 0 || zoom > maxTileZoom {
+		return nil
+	}
+	seen := make(map[Tile]bool)
+	last := Tile{-1, -1}
+	for _, p := range gpx.firstTrkpts() {
+		t := tileOf(p.Lat, p.Lon, zoom)
+		if t == last { //dedupe runs cheaply
+			continue
+		}
+		last = t
+		if !seen[t] {
+			seen[t] = true
+			tiles = append(tiles, t)
+		}
+	}
+	return tiles
+}
+
+// tileOf returns the tile at zoom level zoom containing (lat, lon).
+func tileOf(lat, lon float64, zoom int) Tile {
+	const rad = math.Pi / 180
+	n := float64(int(1) << zoom)
+	lat = min(max(lat, -maxMercatorLat), maxMercatorLat) * rad
+	x := int(math.Floor((lon + 180) / 360 * n))
+	y := int(math.Floor((1 - math.Log(math.Tan(lat)+1/math.Cos(lat))/math.Pi) / 2 * n))
+	last := int(n) - 1
+	return Tile{min(max(x, 0), last), min(max(y, 0), last)}
+}
@@ -0,0 +1,33 @@
+package gpx
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTiles(t *testing.T) {
+	gpx := parseTestdata(t, "dop.gpx")
+	tests := []struct {
+		zoom  int
+		want  []Tile
+		count int
+	}{
+		{-1, nil, 0},
+		{0, []Tile{{0, 0}}, 1},
+		{10, []Tile{{582, 296}, {582, 295}, {581, 295}}, 3},
+		{30, nil, 4},
+		{31, nil, 0},
+		{64, nil, 0},
+	}
+	for _, tt := range tests {
+		got := gpx.Tiles(tt.zoom)
+		if len(got) != tt.count || tt.want != nil && !slices.Equal(got, tt.want) {
+			t.Errorf("zoom %d: got %v, want %d tiles %v", tt.zoom, got, tt.count, tt.want)
+		}
+		for _, tile := range got {
+			if last := 1<<tt.zoom - 1; tile.X < 0 || tile.Y < 0 || tile.X > last || tile.Y > last {
+				t.Errorf("zoom %d: tile %v out of range", tt.zoom, tile)
+			}
+		}
+	}
+}
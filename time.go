@@ -0,0 +1,197 @@
+package gpx
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"time"
+)
+
+// ErrNoTime is returned by time based methods for track points without time.
+var ErrNoTime = errors.New("missing track point time")
+
+/*
+parseTime parses an RFC 3339 time, e.g. 2023-06-19T11:27:32Z. The common
+UTC form, with or without fractional seconds, is parsed without time.Parse,
+which is several times slower. Other forms and days after the 28th, which
+need the month length check of time.Parse, are parsed by time.Parse.
+*/
+func parseTime(b []byte) (time.Time, error) {
+	b = bytes.TrimSpace(b)
+	n := len(b)
+	if n < 20 || b[n-1] != 'Z' || b[4] != '-' || b[7] != '-' || b[10] != 'T' ||
+		b[13] != ':' || b[16] != ':' || n == 21 || (n > 20 && b[19] != '.') {
+		return time.Parse(time.RFC3339Nano, string(b))
+	}
+	year, ok1 := digits(b[0:4])
+	month, ok2 := digits(b[5:7])
+	day, ok3 := digits(b[8:10])
+	hour, ok4 := digits(b[11:13])
+	minute, ok5 := digits(b[14:16])
+	sec, ok6 := digits(b[17:19])
+	nsec, ok7 := 0, true
+	if n > 21 {
+		frac := b[20 : n-1]
+		if len(frac) > 9 {
+			frac = frac[:9]
+		}
+		nsec, ok7 = digits(frac)
+		for i := len(frac); i < 9; i++ {
+			nsec *= 10
+		}
+	}
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6 && ok7) ||
+		month < 1 || month > 12 || day < 1 || day > 28 ||
+		hour > 23 || minute > 59 || sec > 59 {
+		return time.Parse(time.RFC3339Nano, string(b))
+	}
+	return time.Date(year, time.Month(month), day, hour, minute, sec, nsec, time.UTC), nil
+}
+
+// digits returns the value of decimal digits b, false if b has a non-digit.
+func digits(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, true
+}
+
+// Duration returns the elapsed time between the first and the last
+// track point of the first track segment. ErrNoTime is returned if
+// either of them has no time.
+func (gpx *GPX) Duration() (time.Duration, error) {
+	s := gpx.firstTrkpts()
+	if len(s) == 0 || s[0].Time.IsZero() || s[len(s)-1].Time.IsZero() {
+		return 0, ErrNoTime
+	}
+	return s[len(s)-1].Time.Sub(s[0].Time), nil
+}
+
+/*
+MovingTime returns the sum of time intervals between consecutive track
+points of the first track segment, where the speed is at least minSpeed
+m/s. Intervals with a point without time are skipped. ErrNoTime is
+returned if the first or the last track point has no time.
+*/
+func (gpx *GPX) MovingTime(minSpeed float64) (time.Duration, error) {
+	if _, e := gpx.Duration(); e != nil {
+		return 0, e
+	}
+	s := gpx.firstTrkpts()
+	var t time.Duration
+	for i := 1; i < len(s); i++ {
+		if s[i-1].Time.IsZero() || s[i].Time.IsZero() {
+			continue
+		}
+		dt := s[i].Time.Sub(s[i-1].Time)
+		if dt > 0 && dist(s[i-1], s[i]) >= minSpeed*dt.Seconds() {
+			t += dt
+		}
+	}
+	return t, nil
+}
+
+// ValidateTimeMonotonic returns the indices of the track points of the
+// first track segment, whose time is not later than the time of the
+// previous track point. Track points without time are not checked.
+func (gpx *GPX) ValidateTimeMonotonic() []int {
+	s := gpx.firstTrkpts()
+	var idx []int
+	for i := 1; i < len(s); i++ {
+		if s[i-1].Time.IsZero() || s[i].Time.IsZero() {
+			continue
+		}
+		if !s[i].Time.After(s[i-1].Time) {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// FixTimeMonotonic removes the track points of the first track segment,
+// whose time is not later than the time of the last kept track point
+// with time. Track points without time are kept. FixTimeMonotonic
+// returns the number of removed track points.
+func (gpx *GPX) FixTimeMonotonic() int {
+	s := gpx.firstTrkpts()
+	var last time.Time
+	n := 0
+	for _, p := range s {
+		if !p.Time.IsZero() {
+			if !last.IsZero() && !p.Time.After(last) {
+				continue
+			}
+			last = p.Time
+		}
+		s[n] = p
+		n++
+	}
+	if n < len(s) {
+		clear(s[n:])
+		gpx.Trks[0].Trksegs[0].Trkpts = s[:n]
+	}
+	return len(s) - n
+}
+
+// SampleInterval returns the median time between consecutive track points
+// of the first track segment, e.g. 1 s for a one second recording. Pairs
+// with a point without time are skipped. ErrNoTime is returned if there
+// are no such pairs.
+func (gpx *GPX) SampleInterval() (median time.Duration, err error) {
+	s := gpx.firstTrkpts()
+	var dt []float64
+	for i := 1; i < len(s); i++ {
+		if !s[i-1].Time.IsZero() && !s[i].Time.IsZero() {
+			dt = append(dt, float64(s[i].Time.Sub(s[i-1].Time)))
+		}
+	}
+	if len(dt) == 0 {
+		return 0, ErrNoTime
+	}
+	return time.Duration(medianOf(dt)), nil
+}
+
+// medianOf returns the median of x sorting x. x must not be empty.
+func medianOf(x []float64) float64 {
+	sort.Float64s(x)
+	n := len(x)
+	if n%2 == 1 {
+		return x[n/2]
+	}
+	return (x[n/2-1] + x[n/2]) / 2
+}
+
+/*
+ShiftTime adds d to the time of all track points of all tracks and to
+gpx.Time in place, e.g. to fix a wrong time zone setting. Track points
+without time are skipped, and so is a gpx.Time, which is not RFC 3339.
+*/
+func (gpx *GPX) ShiftTime(d time.Duration) {
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			for i := range seg.Trkpts {
+				if t := &seg.Trkpts[i].Time; !t.IsZero() {
+					*t = t.Add(d)
+				}
+			}
+		}
+	}
+	if t, e := time.Parse(time.RFC3339Nano, gpx.Time); e == nil {
+		gpx.Time = t.Add(d).Format(time.RFC3339Nano)
+	}
+}
+
+// SetStartTime shifts the times as in ShiftTime, so that the first track
+// point with time is at t. The intervals between the times are kept.
+func (gpx *GPX) SetStartTime(t time.Time) {
+	for p := range gpx.AllPoints() {
+		if !p.Time.IsZero() {
+			gpx.ShiftTime(t.Sub(p.Time))
+			return
+		}
+	}
+}
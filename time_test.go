@@ -0,0 +1,41 @@
+package gpx
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		in   string
+		want time.Time
+		err  bool
+	}{
+		{"2023-06-19T11:27:32Z", time.Date(2023, 6, 19, 11, 27, 32, 0, time.UTC), false},
+		{"2023-06-19T11:27:32.5Z", time.Date(2023, 6, 19, 11, 27, 32, 5e8, time.UTC), false},
+		{" 2023-06-19T11:27:32.123456789123Z ", time.Date(2023, 6, 19, 11, 27, 32, 123456789, time.UTC), false},
+		{"2023-06-19T13:27:32+02:00", time.Date(2023, 6, 19, 11, 27, 32, 0, time.UTC), false},
+		{"2024-02-29T00:00:00Z", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC), false},
+		{"2023-01-31T23:59:59Z", time.Date(2023, 1, 31, 23, 59, 59, 0, time.UTC), false},
+		{"2023-02-29T00:00:00Z", time.Time{}, true},
+		{"2024-02-30T00:00:00Z", time.Time{}, true},
+		{"2023-02-31T00:00:00Z", time.Time{}, true},
+		{"2023-04-31T00:00:00Z", time.Time{}, true},
+		{"2023-13-01T00:00:00Z", time.Time{}, true},
+		{"2023-06-19T24:00:00Z", time.Time{}, true},
+		{"2023-06-19T11:27:32.Z", time.Time{}, true},
+		{"2023-06-19", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		got, e := parseTime([]byte(tt.in))
+		if tt.err {
+			if e == nil {
+				t.Errorf("%q: got %v, want error", tt.in, got)
+			}
+			continue
+		}
+		if e != nil || !got.Equal(tt.want) {
+			t.Errorf("%q: got %v, %v, want %v", tt.in, got, e, tt.want)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gpx
+
+import "math"
+
+// ShiftElevation adds delta meters to the elevation of each track point
+// of the first track segment in place. Missing (NaN) elevations are kept.
+func (gpx *GPX) ShiftElevation(delta float64) {
+	s := gpx.firstTrkpts()
+	for i := range s {
+		s[i].Ele += delta //NaN stays NaN
+	}
+}
+
+// ScaleElevation multiplies the elevation of each track point of the first
+// track segment by factor in place, e.g. by 0.3048 to convert feet to meters.
+// Missing (NaN) elevations are kept.
+func (gpx *GPX) ScaleElevation(factor float64) {
+	s := gpx.firstTrkpts()
+	for i := range s {
+		s[i].Ele *= factor
+	}
+}
+
+// Drop3D sets the elevation of each track point of the first track segment
+// missing (NaN) in place. Marshal then writes no <ele> elements.
+func (gpx *GPX) Drop3D() {
+	s := gpx.firstTrkpts()
+	for i := range s {
+		s[i].Ele = math.NaN()
+	}
+}
+
+// Default elevation bounds of ClampElevation in meters, a margin below
+// the Dead Sea shore (-430 m) and above Mount Everest (8849 m). Use wider
+// bounds for flights.
+const (
+	DefaultMinElevation = -500.0
+	DefaultMaxElevation = 9000.0
+)
+
+/*
+ClampElevation replaces the elevations of the first track segment below
+min by min and above max by max in place, e.g. with DefaultMinElevation
+and DefaultMaxElevation, and returns the count of replaced elevations.
+It cleans sentinel garbage like -99999 of some devices before elevation
+analytics. Missing (NaN) elevations are kept. See DropElevationOutside
+to set the garbage missing instead.
+*/
+func (gpx *GPX) ClampElevation(min, max float64) int {
+	return gpx.replaceElevation(min, max, func(ele float64) float64 {
+		return math.Min(math.Max(ele, min), max)
+	})
+}
+
+// DropElevationOutside is like ClampElevation, but the elevations out of
+// [min, max] are set missing (NaN), e.g. to refill them by FillElevationGaps.
+func (gpx *GPX) DropElevationOutside(min, max float64) int {
+	return gpx.replaceElevation(min, max, func(float64) float64 {
+		return math.NaN()
+	})
+}
+
+// replaceElevation replaces the elevations out of [min, max] of the first
+// track segment by replace of them and returns their count.
+func (gpx *GPX) replaceElevation(min, max float64, replace func(ele float64) float64) int {
+	s := gpx.firstTrkpts()
+	n := 0
+	for i := range s {
+		if s[i].Ele < min || s[i].Ele > max { //NaN is not
+			s[i].Ele = replace(s[i].Ele)
+			n++
+		}
+	}
+	return n
+}
+
+/*
+SmoothPositions replaces lat and lon of each track point of the first track
+segment in place by the moving average of the window points centered on
+it, which reduces GPS jitter and the distance it adds. The window shrinks
+symmetrically at the ends, so the first and last points are not moved.
+Window < 3 does nothing. This alters the recorded positions: smooth before
+computing distances, not after.
+*/
+func (gpx *GPX) SmoothPositions(window int) {
+	s := gpx.firstTrkpts()
+	half := window / 2
+	if half < 1 || len(s) < 3 {
+		return
+	}
+	orig := make([]Trkpt, len(s))
+	copy(orig, s)
+	for i := 1; i < len(s)-1; i++ {
+		h := min(half, i, len(s)-1-i)
+		var lat, dlon float64
+		for j := i - h; j <= i+h; j++ {
+			lat += orig[j].Lat
+			dlon += lonDelta(orig[i].Lon, orig[j].Lon) //across the antimeridian
+		}
+		n := float64(2*h + 1)
+		s[i].Lat = lat / n
+		s[i].Lon = normLon(orig[i].Lon + dlon/n)
+	}
+}
+
+/*
+RoundCoordinates rounds lat and lon of all track points of all tracks in
+place to decimals decimals, ties to even, e.g. 7 is about 1 cm and 4
+about 11 m. Unlike WriteOptions.CoordDecimals, this changes the stored
+values, so distances are computed from the rounded positions. A small
+decimals coarsens positions for privacy, e.g. of a home location.
+*/
+func (gpx *GPX) RoundCoordinates(decimals int) {
+	scale := math.Pow(10, float64(decimals))
+	round := func(f float64) float64 {
+		return math.RoundToEven(f*scale) / scale
+	}
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			for i := range seg.Trkpts {
+				p := &seg.Trkpts[i]
+				p.Lat, p.Lon = round(p.Lat), round(p.Lon)
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package gpx
+
+import "bytes"
+
+/*
+Validate checks GPX data like ParseGPX without building the track point
+slice. It returns the first error: no track points, unbalanced <trkpt>
+and </trkpt> tags or a track point error.
+*/
+func Validate(gpxbytes []byte) error {
+	var trkpSlice []byte
+
+	p := &parser{opts: ParseOptions{}, data: gpxbytes}
+	p.setTags()
+	b, e := p.selectTrkSegment(gpxbytes)
+	if e != nil {
+		return e
+	}
+	p.init(b)
+	n := 1
+	for ; ; n++ {
+		trkpSlice, b = p.nextTrkpt(b)
+		if trkpSlice == nil {
+			break
+		}
+		if bytes.Index(trkpSlice, p.starttag) >= 0 { //indexTag may skip it, as in next
+			return errf("trackpoint %d: %w", n, ErrUnbalanced)
+		}
+		if _, e := p.parseTrkpt(trkpSlice); e != nil {
+			return errf("trackpoint %d: %s: %w: %s", n, p.position(trkpSlice), e, trkpSlice)
+		}
+	}
+	if indexTag(b, p.starttag) >= 0 {
+		return errf("trackpoint %d: %w", n, ErrUnbalanced)
+	}
+	return nil
+}
+
+/*
+CheckXML checks that the elements of XML data are balanced: each start
+tag has a matching end tag in the right nesting order, and that there is
+a single root element. This catches e.g. truncated or concatenated
+files, and empty data without a root element. Comments, CDATA sections,
+processing instructions and declarations are skipped. Attributes, names
+and text are not checked, so CheckXML is much cheaper than encoding/xml.
+The error wraps ErrMalformedXML and gives the byte offset.
+*/
+func CheckXML(data []byte) error {
+	var stack [][]byte
+
+	root := false //the root element has been closed
+	b := data
+	for {
+		l := indexByte(b, '<')
+		if l < 0 {
+			break
+		}
+		b = b[l:]
+		off := len(data) - len(b)
+		if end, ok := skipMarkup(b); ok {
+			if end < 0 {
+				return errf("%w: offset %d: unterminated markup", ErrMalformedXML, off)
+			}
+			b = b[end:]
+			continue
+		}
+		g := indexByte(b, '>')
+		if g < 0 {
+			return errf("%w: offset %d: unterminated tag", ErrMalformedXML, off)
+		}
+		tag := b[1:g]
+		b = b[g+1:]
+		if root && (len(tag) == 0 || tag[0] != '/') {
+			return errf("%w: offset %d: second root element", ErrMalformedXML, off)
+		}
+		switch {
+		case len(tag) > 0 && tag[0] == '/':
+			name := trimSpace(tag[1:])
+			if len(stack) == 0 || !bytes.Equal(stack[len(stack)-1], name) {
+				return errf("%w: offset %d: unexpected </%s>", ErrMalformedXML, off, name)
+			}
+			stack = stack[:len(stack)-1]
+			root = len(stack) == 0
+		case len(tag) > 0 && tag[len(tag)-1] == '/': //empty element
+			root = len(stack) == 0
+		default:
+			n := 0
+			for n < len(tag) && tag[n] > ' ' {
+				n++
+			}
+			stack = append(stack, tag[:n])
+		}
+	}
+	if len(stack) > 0 {
+		return errf("%w: missing </%s>", ErrMalformedXML, stack[len(stack)-1])
+	}
+	if !root {
+		return errf("%w: no root element", ErrMalformedXML)
+	}
+	return nil
+}
+
+// skipMarkup returns the length of the comment, CDATA section, processing
+// instruction or declaration at the start of b, -1 if it is unterminated.
+// false is returned if b does not start with one of them.
+func skipMarkup(b []byte) (int, bool) {
+	for _, m := range [...]struct{ start, end string }{
+		{"<!--", "-->"},
+		{"<![CDATA[", "]]>"},
+		{"<?", "?>"},
+		{"<!", ">"},
+	} {
+		if bytes.HasPrefix(b, []byte(m.start)) {
+			r := bytes.Index(b[len(m.start):], []byte(m.end))
+			if r < 0 {
+				return -1, true
+			}
+			return len(m.start) + r + len(m.end), true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,61 @@
+package gpx
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckXML(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		ok   bool
+	}{
+		{"multitrack.gpx", readTestdata(t, "multitrack.gpx"), true},
+		{"embedded.xml", readTestdata(t, "embedded.xml"), true},
+		{"selfclosing.gpx", readTestdata(t, "selfclosing.gpx"), true},
+		{"concatenated.gpx", readTestdata(t, "concatenated.gpx"), false},
+		{"truncated.gpx", readTestdata(t, "truncated.gpx"), false},
+		{"truncated_trkpt.gpx", readTestdata(t, "truncated_trkpt.gpx"), false},
+		{"comment after root", []byte("<gpx><trk/></gpx>\n<!-- end -->\n"), true},
+		{"empty root", []byte(`<?xml version="1.0"?><gpx/>`), true},
+		{"two roots", []byte("<gpx></gpx><gpx></gpx>"), false},
+		{"empty second root", []byte("<gpx></gpx><gpx/>"), false},
+		{"second root after empty root", []byte("<gpx/><gpx></gpx>"), false},
+		{"unexpected end tag", []byte("<gpx></trk></gpx>"), false},
+		{"unterminated comment", []byte("<gpx><!-- x</gpx>"), false},
+		{"empty", nil, false},
+		{"whitespace", []byte(" \n\t"), false},
+		{"declaration only", []byte(`<?xml version="1.0"?><!-- x -->`), false},
+	}
+	for _, tt := range tests {
+		err := CheckXML(tt.data)
+		if tt.ok && err != nil || !tt.ok && !errors.Is(err, ErrMalformedXML) {
+			t.Errorf("%s: err = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+	_, err := Parse(readTestdata(t, "concatenated.gpx"), ParseOptions{StrictXML: true})
+	if !errors.Is(err, ErrMalformedXML) {
+		t.Errorf("StrictXML concatenated.gpx: err = %v, want %v", err, ErrMalformedXML)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		file string
+		err  error
+	}{
+		{"short.gpx", nil},
+		{"crlf.gpx", nil},
+		{"overlong_trkpt.gpx", nil},
+		{"truncated_trkpt.gpx", ErrUnbalanced},
+		{"corrupted.gpx", ErrUnbalanced},
+		{"selfclosing.gpx", ErrMissingElevation},
+		{"empty.gpx", ErrNoTrackpoints},
+	}
+	for _, tt := range tests {
+		if err := Validate(readTestdata(t, tt.file)); !errors.Is(err, tt.err) {
+			t.Errorf("%s: err = %v, want %v", tt.file, err, tt.err)
+		}
+	}
+}
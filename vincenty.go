@@ -0,0 +1,86 @@
+package gpx
+
+import "math"
+
+// WGS-84 ellipsoid
+const (
+	wgs84A = 6378137.0             // semi-major axis in meters
+	wgs84F = 1 / 298.257223563     // flattening
+	wgs84B = wgs84A * (1 - wgs84F) // semi-minor axis in meters
+)
+
+/*
+DistanceVincenty returns the length of the first track segment in meters
+on the WGS-84 ellipsoid, computed with the Vincenty inverse formula.
+It is accurate to about a millimeter, while the spherical haversine
+Distance can be off by up to 0.5 %. DistanceVincenty is several times
+slower than Distance. For nearly antipodal consecutive points, where
+the Vincenty iteration does not converge, haversine distance is used.
+*/
+func (gpx *GPX) DistanceVincenty() float64 {
+	return pathLength(gpx.firstTrkpts(), distVincenty)
+}
+
+// distVincenty returns the Vincenty distance in meters between track
+// points p and q, or the haversine distance if the iteration fails.
+func distVincenty(p, q Trkpt) float64 {
+	if d, ok := vincenty(p.Lat, p.Lon, q.Lat, q.Lon); ok {
+		return d
+	}
+	return dist(p, q)
+}
+
+// vincenty returns the ellipsoidal distance in meters between points
+// (lat1, lon1) and (lat2, lon2) given in degrees, false if the
+// iteration does not converge.
+func vincenty(lat1, lon1, lat2, lon2 float64) (float64, bool) {
+	const (
+		rad     = math.Pi / 180
+		maxIter = 200
+		eps     = 1e-12
+	)
+	L := lonDelta(lon1, lon2) * rad
+	U1 := math.Atan((1 - wgs84F) * math.Tan(lat1*rad))
+	U2 := math.Atan((1 - wgs84F) * math.Tan(lat2*rad))
+	sinU1, cosU1 := math.Sincos(U1)
+	sinU2, cosU2 := math.Sincos(U2)
+
+	var sinSigma, cosSigma, sigma, cosSqAlpha, cos2SigmaM float64
+	lambda := L
+	for i := 0; ; i++ {
+		if i == maxIter {
+			return 0, false
+		}
+		sinLambda, cosLambda := math.Sincos(lambda)
+		t := cosU1*sinU2 - sinU1*cosU2*cosLambda
+		sinSigma = math.Sqrt(cosU2*sinLambda*cosU2*sinLambda + t*t)
+		if sinSigma == 0 {
+			return 0, true // coincident points
+		}
+		cosSigma = sinU1*sinU2 + cosU1*cosU2*cosLambda
+		sigma = math.Atan2(sinSigma, cosSigma)
+		sinAlpha := cosU1 * cosU2 * sinLambda / sinSigma
+		cosSqAlpha = 1 - sinAlpha*sinAlpha
+		cos2SigmaM = 0 // equatorial line
+		if cosSqAlpha != 0 {
+			cos2SigmaM = cosSigma - 2*sinU1*sinU2/cosSqAlpha
+		}
+		C := wgs84F / 16 * cosSqAlpha * (4 + wgs84F*(4-3*cosSqAlpha))
+		prev := lambda
+		lambda = L + (1-C)*wgs84F*sinAlpha*
+			(sigma+C*sinSigma*(cos2SigmaM+C*cosSigma*(-1+2*cos2SigmaM*cos2SigmaM)))
+		if math.Abs(lambda-prev) < eps {
+			break
+		}
+		if math.Abs(lambda) > math.Pi {
+			return 0, false
+		}
+	}
+	uSq := cosSqAlpha * (wgs84A*wgs84A - wgs84B*wgs84B) / (wgs84B * wgs84B)
+	A := 1 + uSq/16384*(4096+uSq*(-768+uSq*(320-175*uSq)))
+	B := uSq / 1024 * (256 + uSq*(-128+uSq*(74-47*uSq)))
+	c2 := cos2SigmaM * cos2SigmaM
+	deltaSigma := B * sinSigma * (cos2SigmaM + B/4*(cosSigma*(-1+2*c2)-
+		B/6*cos2SigmaM*(-3+4*sinSigma*sinSigma)*(-3+4*c2)))
+	return wgs84B * A * (sigma - deltaSigma), true
+}
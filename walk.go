@@ -0,0 +1,71 @@
+package gpx
+
+import (
+	"compress/gzip"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+/*
+WalkDir parses each .gpx and .gpx.gz file in the file tree rooted at root
+as NewWithOptions, in lexical order, and calls visit with the file path
+and the GPX. Other files are skipped. The walk stops, when visit returns
+stop true or an error, which is returned. A file, which fails to parse,
+is not visited and its error prefixed by the file path is joined to the
+returned error, so that the walk continues. Use opts.MaxBytes to skip
+large files cheaply: a .gpx file is not read and a .gpx.gz file is read
+only up to MaxBytes. Only one GPX is in memory at a time, unless visit
+retains them.
+*/
+func WalkDir(root string, opts ParseOptions, visit func(path string, gpx *GPX) (stop bool, err error)) error {
+	var errs []error
+
+	e := filepath.WalkDir(root, func(path string, d fs.DirEntry, e error) error {
+		if e != nil {
+			return e
+		}
+		name := strings.ToLower(d.Name())
+		if d.IsDir() || !strings.HasSuffix(name, ".gpx") && !strings.HasSuffix(name, ".gpx.gz") {
+			return nil
+		}
+		gpx, e := walkFile(path, opts)
+		if e != nil {
+			errs = append(errs, e)
+			return nil
+		}
+		stop, e := visit(path, gpx)
+		switch {
+		case e != nil:
+			return e
+		case stop:
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return errors.Join(append(errs, e)...)
+}
+
+// walkFile parses file path, which is gzip compressed if its name ends
+// with .gz. Errors are prefixed by path.
+func walkFile(path string, opts ParseOptions) (*GPX, error) {
+	if !strings.HasSuffix(strings.ToLower(path), ".gz") {
+		return NewWithOptions(path, opts)
+	}
+	f, e := os.Open(path)
+	if e != nil {
+		return nil, errf("%w", e)
+	}
+	defer f.Close()
+	r, e := gzip.NewReader(f)
+	if e != nil {
+		return nil, errf("%s: %w", path, e)
+	}
+	gpx, e := NewReader(r, opts)
+	if e != nil {
+		return nil, errf("%s: %w", path, e)
+	}
+	return gpx, nil
+}
@@ -0,0 +1,323 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"strconv"
+	"time"
+)
+
+// WriteOptions controls number formatting in Marshal and WriteCSV.
+type WriteOptions struct {
+	CoordDecimals int // max decimals of lat and lon, 7 decimals is about 1 cm
+	EleDecimals   int // max decimals of elevation
+}
+
+// DefaultWriteOptions is used by Marshal and WriteCSV.
+var DefaultWriteOptions = WriteOptions{CoordDecimals: 7, EleDecimals: 2}
+
+const defaultCreator = "github.com/pekkizen/gpx"
+
+// FormatCoord returns f formatted with at most decimals decimals, without
+// exponent and trailing zeros. f is rounded to nearest, ties to even.
+func FormatCoord(f float64, decimals int) string {
+	return string(appendFloat(nil, f, decimals))
+}
+
+// appendFloat appends f formatted as in FormatCoord to b.
+func appendFloat(b []byte, f float64, decimals int) []byte {
+	l := len(b)
+	b = strconv.AppendFloat(b, f, 'f', max(decimals, 0), 64)
+	if decimals > 0 {
+		b = bytes.TrimRight(b, "0")
+		b = bytes.TrimSuffix(b, []byte("."))
+	}
+	if string(b[l:]) == "-0" {
+		b = append(b[:l], '0')
+	}
+	return b
+}
+
+// Marshal returns gpx as GPX 1.1 XML data. All tracks and track segments
+// are written with track names, comments and descriptions, point times
+// and TrkptExtra values, which ParseGPX reads back. ParseGPX merges all
+// track points to one segment, the XML parser keeps the structure.
+// Track points with a NaN or infinite lat or lon, e.g. of
+// PartialCoordinates, are skipped, as are they by all writers.
+// Numbers are formatted by DefaultWriteOptions.
+func (gpx *GPX) Marshal() ([]byte, error) {
+	return gpx.MarshalWithOptions(DefaultWriteOptions)
+}
+
+// MarshalWithOptions is like Marshal, but numbers are formatted by opts.
+func (gpx *GPX) MarshalWithOptions(opts WriteOptions) ([]byte, error) {
+	w := &bufWriter{b: make([]byte, 0, 256+80*gpx.TotalPoints())}
+	gpx.encode(w, opts)
+	return w.b, nil
+}
+
+// EncodeTo writes gpx to w as in Marshal. The data is written through a
+// small reusable buffer, so memory use does not grow with the track size.
+func (gpx *GPX) EncodeTo(w io.Writer) error {
+	return gpx.EncodeToWithOptions(w, DefaultWriteOptions)
+}
+
+// EncodeToWithOptions is like EncodeTo, but numbers are formatted by opts.
+func (gpx *GPX) EncodeToWithOptions(w io.Writer, opts WriteOptions) error {
+	bw := &bufWriter{w: w, b: make([]byte, 0, writeBufSize)}
+	gpx.encode(bw, opts)
+	return bw.flush()
+}
+
+// encode writes gpx as GPX XML to w.
+func (gpx *GPX) encode(w *bufWriter, opts WriteOptions) {
+	w.b = gpx.appendHeader(w.b, opts)
+	for _, trk := range gpx.Trks {
+		w.b = appendTrkStart(w.b, &trk)
+		for _, seg := range trk.Trksegs {
+			w.b = append(w.b, "  <trkseg>\n"...)
+			for _, p := range seg.Trkpts {
+				if !hasCoords(p) {
+					continue
+				}
+				w.b = appendTrkpt(w.b, p, opts)
+				w.maybeFlush()
+			}
+			w.b = append(w.b, "  </trkseg>\n"...)
+		}
+		w.b = append(w.b, " </trk>\n"...)
+	}
+	w.b = append(w.b, "</gpx>\n"...)
+}
+
+const writeBufSize = 64 * 1024
+
+// bufWriter is an append buffer flushed to w when it is nearly full.
+// If w is nil, the buffer is never flushed and it grows as needed.
+// The first write error is kept and later writes are skipped.
+type bufWriter struct {
+	w   io.Writer
+	b   []byte
+	err error
+}
+
+// maybeFlush flushes w, if the buffer has less than 1 kB free capacity.
+func (w *bufWriter) maybeFlush() {
+	if w.w != nil && len(w.b) > cap(w.b)-1024 {
+		w.flush()
+	}
+}
+
+// flush writes the buffer and returns the first write error.
+func (w *bufWriter) flush() error {
+	if w.w != nil && w.err == nil && len(w.b) > 0 {
+		_, w.err = w.w.Write(w.b)
+	}
+	w.b = w.b[:0]
+	return w.err
+}
+
+// appendHeader appends the XML declaration and the <gpx> start tag to b.
+func (gpx *GPX) appendHeader(b []byte, opts WriteOptions) []byte {
+	creator := gpx.Creator
+	if creator == "" {
+		creator = defaultCreator
+	}
+	b = append(b, xml.Header...)
+	b = append(b, `<gpx version="1.1" creator="`...)
+	b = appendEscaped(b, creator)
+	b = append(b, "\" xmlns=\"http://www.topografix.com/GPX/1/1\""...)
+	b = append(b, " xmlns:gpxtpx=\"http://www.garmin.com/xmlschemas/TrackPointExtension/v1\">\n"...)
+	return gpx.appendMetadata(b, opts)
+}
+
+// appendMetadata appends the <metadata> element with Metadata, <time>
+// and <bounds> of all track points to b. Empty elements are not written.
+func (gpx *GPX) appendMetadata(b []byte, opts WriteOptions) []byte {
+	m := gpx.Metadata
+	bounds, ok := gpx.allBounds()
+	if !ok && gpx.Time == "" && m == (Metadata{}) {
+		return b
+	}
+	b = append(b, " <metadata>\n"...)
+	if m.Author != "" {
+		b = append(b, "  <author><name>"...)
+		b = appendEscaped(b, m.Author)
+		b = append(b, "</name></author>\n"...)
+	}
+	if m.Link.Href != "" {
+		b = append(b, `  <link href="`...)
+		b = appendEscaped(b, m.Link.Href)
+		b = append(b, `">`...)
+		if m.Link.Text != "" {
+			b = append(b, "<text>"...)
+			b = appendEscaped(b, m.Link.Text)
+			b = append(b, "</text>"...)
+		}
+		b = append(b, "</link>\n"...)
+	}
+	if gpx.Time != "" {
+		b = append(b, "  <time>"...)
+		b = appendEscaped(b, gpx.Time)
+		b = append(b, "</time>\n"...)
+	}
+	if m.Keywords != "" {
+		b = append(b, "  <keywords>"...)
+		b = appendEscaped(b, m.Keywords)
+		b = append(b, "</keywords>\n"...)
+	}
+	if ok {
+		decimals := opts.CoordDecimals //rounded as the track points
+		b = append(b, `  <bounds minlat="`...)
+		b = appendFloat(b, bounds.MinLat, decimals)
+		b = append(b, `" minlon="`...)
+		b = appendFloat(b, bounds.MinLon, decimals)
+		b = append(b, `" maxlat="`...)
+		b = appendFloat(b, bounds.MaxLat, decimals)
+		b = append(b, `" maxlon="`...)
+		b = appendFloat(b, bounds.MaxLon, decimals)
+		b = append(b, "\"/>\n"...)
+	}
+	return append(b, " </metadata>\n"...)
+}
+
+// appendTrkStart appends the <trk> start tag and the track name, comment
+// and description to b. Empty ones are not written.
+func appendTrkStart(b []byte, trk *Trk) []byte {
+	b = append(b, " <trk>\n"...)
+	b = appendText(b, "name", trk.Name)
+	b = appendText(b, "cmt", trk.Cmt)
+	return appendText(b, "desc", trk.Desc)
+}
+
+// appendText appends element <name>s</name> to b, if s is not empty.
+func appendText(b []byte, name, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = append(b, "  <"...)
+	b = append(b, name...)
+	b = append(b, '>')
+	b = appendEscaped(b, s)
+	b = append(b, "</"...)
+	b = append(b, name...)
+	return append(b, ">\n"...)
+}
+
+// hasCoords reports whether track point p has finite lat and lon. The
+// writers skip the other track points, as the output formats have no
+// missing coordinates.
+func hasCoords(p Trkpt) bool {
+	return !math.IsNaN(p.Lat) && !math.IsInf(p.Lat, 0) && !math.IsNaN(p.Lon) && !math.IsInf(p.Lon, 0)
+}
+
+// appendTrkpt appends track point p as a <trkpt> element to b.
+// Missing elevation and time are not written.
+func appendTrkpt(b []byte, p Trkpt, opts WriteOptions) []byte {
+	b = append(b, `   <trkpt lat="`...)
+	b = appendFloat(b, p.Lat, opts.CoordDecimals)
+	b = append(b, `" lon="`...)
+	b = appendFloat(b, p.Lon, opts.CoordDecimals)
+	b = append(b, `">`...)
+	if p.HasEle() {
+		b = append(b, "<ele>"...)
+		b = appendFloat(b, p.Ele, opts.EleDecimals)
+		b = append(b, "</ele>"...)
+	}
+	if !p.Time.IsZero() {
+		b = append(b, "<time>"...)
+		b = p.Time.UTC().AppendFormat(b, time.RFC3339Nano)
+		b = append(b, "</time>"...)
+	}
+	if x := p.Extra; x != nil {
+		b = appendElement(b, "magvar", x.MagVar, -1)
+		b = appendElement(b, "geoidheight", x.GeoidHeight, opts.EleDecimals)
+		b = appendElement(b, "sat", x.Sat, -1)
+		b = appendElement(b, "hdop", x.HDOP, -1)
+		b = appendElement(b, "vdop", x.VDOP, -1)
+		b = appendElement(b, "pdop", x.PDOP, -1)
+		b = appendExtensions(b, x)
+	}
+	return append(b, "</trkpt>\n"...)
+}
+
+// appendExtensions appends the <extensions> element with power and the
+// Garmin TrackPointExtension hr and cad of x to b, if x has any of them.
+func appendExtensions(b []byte, x *TrkptExtra) []byte {
+	hasTPX := !math.IsNaN(x.HR) || !math.IsNaN(x.Cad)
+	if !hasTPX && math.IsNaN(x.Power) {
+		return b
+	}
+	b = append(b, "<extensions>"...)
+	b = appendElement(b, "power", x.Power, -1)
+	if hasTPX {
+		b = append(b, "<gpxtpx:TrackPointExtension>"...)
+		b = appendElement(b, "gpxtpx:hr", x.HR, -1)
+		b = appendElement(b, "gpxtpx:cad", x.Cad, -1)
+		b = append(b, "</gpxtpx:TrackPointExtension>"...)
+	}
+	return append(b, "</extensions>"...)
+}
+
+// appendElement appends element <name>f</name> to b, if f is not NaN.
+// f is formatted with at most decimals decimals, all if decimals < 0.
+func appendElement(b []byte, name string, f float64, decimals int) []byte {
+	if math.IsNaN(f) {
+		return b
+	}
+	b = append(b, '<')
+	b = append(b, name...)
+	b = append(b, '>')
+	if decimals < 0 {
+		b = strconv.AppendFloat(b, f, 'f', -1, 64)
+	} else {
+		b = appendFloat(b, f, decimals)
+	}
+	b = append(b, "</"...)
+	b = append(b, name...)
+	return append(b, '>')
+}
+
+// WriteCSV writes all track points to w as CSV lines lat,lon,ele,time
+// with a header line. Missing elevation and time are empty fields, points
+// without valid lat and lon are skipped as in Marshal.
+// Numbers are formatted by DefaultWriteOptions.
+func (gpx *GPX) WriteCSV(w io.Writer) error {
+	return gpx.WriteCSVWithOptions(w, DefaultWriteOptions)
+}
+
+// WriteCSVWithOptions is like WriteCSV, but numbers are formatted by opts.
+func (gpx *GPX) WriteCSVWithOptions(w io.Writer, opts WriteOptions) error {
+	bw := &bufWriter{w: w, b: make([]byte, 0, writeBufSize)}
+	bw.b = append(bw.b, "lat,lon,ele,time\n"...)
+	for _, trk := range gpx.Trks {
+		for _, seg := range trk.Trksegs {
+			for _, p := range seg.Trkpts {
+				if !hasCoords(p) {
+					continue
+				}
+				bw.b = appendCSV(bw.b, p, opts)
+				bw.maybeFlush()
+			}
+		}
+	}
+	return bw.flush()
+}
+
+// appendCSV appends track point p as a CSV line lat,lon,ele,time to b.
+func appendCSV(b []byte, p Trkpt, opts WriteOptions) []byte {
+	b = appendFloat(b, p.Lat, opts.CoordDecimals)
+	b = append(b, ',')
+	b = appendFloat(b, p.Lon, opts.CoordDecimals)
+	b = append(b, ',')
+	if p.HasEle() {
+		b = appendFloat(b, p.Ele, opts.EleDecimals)
+	}
+	b = append(b, ',')
+	if !p.Time.IsZero() {
+		b = p.Time.UTC().AppendFormat(b, time.RFC3339Nano)
+	}
+	return append(b, '\n')
+}
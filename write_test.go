@@ -0,0 +1,145 @@
+package gpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMarshalRoundTrip(t *testing.T) {
+	tests := []struct {
+		file string
+		opts ParseOptions
+	}{
+		{"multitrack.gpx", lenient},
+		{"dop.gpx", lenient},
+		{"magvar.gpx", lenient},
+		{"selfclosing.gpx", lenient},
+		{"emptyele.gpx", lenient},
+	}
+	for _, tt := range tests {
+		gpx, err := Parse(readTestdata(t, tt.file), tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		b, err := gpx.Marshal()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		r, err := Parse(b, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: reparse: %v\n%s", tt.file, err, b)
+		}
+		s, rs := gpx.TrkpSlice(), r.TrkpSlice()
+		if len(rs) != len(s) {
+			t.Fatalf("%s: %d points after round trip, want %d", tt.file, len(rs), len(s))
+		}
+		for i := range s {
+			if !sameTrkpt(rs[i], s[i]) {
+				t.Errorf("%s: point %d = %v %v, want %v %v", tt.file, i, rs[i], rs[i].Extra, s[i], s[i].Extra)
+			}
+		}
+	}
+}
+
+func TestMarshalTimeAndExtensions(t *testing.T) {
+	gpx := parseTestdata(t, "multitrack.gpx")
+	b, err := gpx.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`xmlns:gpxtpx="http://www.garmin.com/xmlschemas/TrackPointExtension/v1"`,
+		"<time>2024-05-01T10:00:00Z</time>",
+		"<gpxtpx:hr>120</gpxtpx:hr><gpxtpx:cad>80</gpxtpx:cad>",
+		`<trkpt lat="60.3" lon="24.7"><time>2024-05-01T10:05:00Z</time></trkpt>`,
+	} {
+		if !bytes.Contains(b, []byte(want)) {
+			t.Errorf("no %s in\n%s", want, b)
+		}
+	}
+	if n := bytes.Count(b, []byte("<extensions>")); n != 2 {
+		t.Errorf("%d <extensions>, want 2", n)
+	}
+	r, err := Parse(b, lenient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := r.TrkpSlice()[0]
+	if !p.Time.Equal(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)) || p.Extra == nil ||
+		p.Extra.HR != 120 || p.Extra.Cad != 80 {
+		t.Errorf("point 0 = %v %v", p, p.Extra)
+	}
+}
+
+func TestMarshalStructure(t *testing.T) {
+	tests := []string{"multitrack.gpx", "cmtdesc.gpx", "entities.gpx", "gpx10.gpx"}
+	opts := ParseOptions{UseXMLParser: true}
+	for _, file := range tests {
+		gpx, err := Parse(readTestdata(t, file), opts)
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		b, err := gpx.Marshal()
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		r, err := Parse(b, opts)
+		if err != nil {
+			t.Fatalf("%s: reparse: %v\n%s", file, err, b)
+		}
+		if file == "multitrack.gpx" && (len(gpx.Trks) != 2 || len(gpx.Trks[0].Trksegs) != 2) {
+			t.Fatalf("%s: %d tracks, want 2 with 2 and 1 segments", file, len(gpx.Trks))
+		}
+		r.Version = gpx.Version //written as 1.1
+		if !sameGPX(r, gpx) {
+			t.Errorf("%s: structure changed in round trip\n%s", file, b)
+		}
+	}
+}
+
+func TestWriteInvalidCoordinates(t *testing.T) {
+	gpx := parseTestdata(t, "short.gpx")
+	s := gpx.TrkpSlice()
+	s[1].Lat = nan
+	s[2].Lon = math.Inf(1)
+	want := Trkpt{Lat: 1, Lon: 2, Ele: 3}
+	var kml, geojson, csv bytes.Buffer
+	for _, err := range []error{gpx.WriteKML(&kml), gpx.WriteGeoJSON(&geojson), gpx.WriteCSV(&csv)} {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	b, err := gpx.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name, data, want string
+	}{
+		{"Marshal", string(b), "<trkseg>\n   " + `<trkpt lat="1" lon="2"><ele>3</ele></trkpt>` + "\n  </trkseg>"},
+		{"Marshal bounds", string(b), `<bounds minlat="1" minlon="2" maxlat="1" maxlon="2"/>`},
+		{"WriteKML", kml.String(), "<coordinates>\n   2,1,3\n  </coordinates>"},
+		{"WriteGeoJSON", geojson.String(), `"coordinates":[[[2,1,3]]]`},
+		{"WriteCSV", csv.String(), "lat,lon,ele,time\n1,2,3,\n"},
+		{"Polyline", gpx.Polyline(5), (&GPX{Trks: []Trk{{Trksegs: []Trkseg{{Trkpts: []Trkpt{want}}}}}}).Polyline(5)},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(tt.data, tt.want) || strings.Contains(tt.data, "NaN") ||
+			strings.Contains(tt.data, "Inf") {
+			t.Errorf("%s: no %q or invalid coordinates in\n%s", tt.name, tt.want, tt.data)
+		}
+	}
+	for _, p := range s[1:] {
+		if _, err := json.Marshal(p); !errors.Is(err, ErrInvalidCoordinate) {
+			t.Errorf("json.Marshal(%v) err = %v, want %v", p, err, ErrInvalidCoordinate)
+		}
+	}
+	if _, err := json.Marshal(gpx); !errors.Is(err, ErrInvalidCoordinate) {
+		t.Errorf("json.Marshal(gpx) err = %v, want %v", err, ErrInvalidCoordinate)
+	}
+}
@@ -0,0 +1,56 @@
+package gpx
+
+import (
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"strings"
+)
+
+/*
+NewZip parses each .gpx and .gpx.gz file of ZIP archive zipPath as
+NewWithOptions and returns the GPXs in archive order. Other files are
+skipped. A file, which fails to parse, has a GPX without tracks and
+its error prefixed by the file name is joined to the returned error,
+so that the other files are still returned.
+*/
+func NewZip(zipPath string, opts ParseOptions) ([]*GPX, error) {
+	var gpxs []*GPX
+	var errs []error
+
+	z, e := zip.OpenReader(zipPath)
+	if e != nil {
+		return nil, errf("%w", e)
+	}
+	defer z.Close()
+	for _, f := range z.File {
+		name := strings.ToLower(f.Name)
+		if !strings.HasSuffix(name, ".gpx") && !strings.HasSuffix(name, ".gpx.gz") {
+			continue
+		}
+		gpx, e := zipEntry(f, opts)
+		if e != nil {
+			errs = append(errs, errf("%s: %s: %w", zipPath, f.Name, e))
+		}
+		gpxs = append(gpxs, gpx)
+	}
+	return gpxs, errors.Join(errs...)
+}
+
+// zipEntry parses ZIP file f, which is gzip compressed if its name
+// ends with .gz.
+func zipEntry(f *zip.File, opts ParseOptions) (*GPX, error) {
+	rc, e := f.Open()
+	if e != nil {
+		return &GPX{}, e
+	}
+	defer rc.Close()
+	if !strings.HasSuffix(strings.ToLower(f.Name), ".gz") {
+		return NewReader(rc, opts)
+	}
+	r, e := gzip.NewReader(rc)
+	if e != nil {
+		return &GPX{}, e
+	}
+	return NewReader(r, opts)
+}
@@ -0,0 +1,64 @@
+package gpx
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrZoneBounds is returned by TimeInZones for bounds, which are not in
+// increasing order.
+var ErrZoneBounds = errors.New("zone bounds not increasing")
+
+/*
+TimeInZones returns the time of the first track segment in each zone of
+track point field, "speed" in m/s or a field of RollingAverage, e.g.
+"hr" or "power". The n bounds define n+1 zones: zone 0 is below bounds[0],
+zone k is from bounds[k-1], inclusive, to bounds[k], exclusive, and zone
+n is from bounds[n-1] on. The time interval between consecutive points
+is assigned to the zone of its value: the speed between the points or
+the field value of the later point, as in RollingAverage. Intervals with
+a point without time or a missing value are skipped. ErrUnknownField is
+returned for an unknown field, ErrZoneBounds for bounds not increasing
+and ErrNoTime, if no interval has time and value.
+*/
+func (gpx *GPX) TimeInZones(bounds []float64, field string) ([]time.Duration, error) {
+	var v []float64
+
+	for k, b := range bounds {
+		if math.IsNaN(b) || k > 0 && !(bounds[k-1] < b) {
+			return nil, ErrZoneBounds
+		}
+	}
+	if field != "speed" {
+		var e error
+		if v, e = gpx.series(field); e != nil {
+			return nil, e
+		}
+	}
+	s := gpx.firstTrkpts()
+	zones := make([]time.Duration, len(bounds)+1)
+	found := false
+	for i := 1; i < len(s); i++ {
+		if s[i-1].Time.IsZero() || s[i].Time.IsZero() {
+			continue
+		}
+		dt := s[i].Time.Sub(s[i-1].Time)
+		if dt <= 0 {
+			continue
+		}
+		var f float64
+		if v == nil {
+			f = dist(s[i-1], s[i]) / dt.Seconds()
+		} else if f = v[i]; math.IsNaN(f) {
+			continue
+		}
+		zones[sort.Search(len(bounds), func(k int) bool { return bounds[k] > f })] += dt
+		found = true
+	}
+	if !found {
+		return nil, ErrNoTime
+	}
+	return zones, nil
+}